@@ -0,0 +1,107 @@
+package ctxerr
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// KV returns the key/value pairs as attributes, following the same rules as
+// the arguments to slog.Logger.Info.
+func KV(args ...any) []slog.Attr {
+	var attrs []slog.Attr
+	for len(args) > 0 {
+		switch x := args[0].(type) {
+		case string:
+			if len(args) == 1 {
+				attrs = append(attrs, slog.String(badKey, x))
+				args = nil
+				continue
+			}
+			attrs = append(attrs, slog.Any(x, args[1]))
+			args = args[2:]
+		case slog.Attr:
+			attrs = append(attrs, x)
+			args = args[1:]
+		default:
+			attrs = append(attrs, slog.Any(badKey, x))
+			args = args[1:]
+		}
+	}
+	return attrs
+}
+
+// Precedence selects which value is kept when the same attribute key is
+// found on more than one *Error in a chain.
+type Precedence int
+
+const (
+	// Outermost keeps the value from the *Error closest to the caller.
+	Outermost Precedence = iota
+	// Innermost keeps the value from the *Error closest to the cause.
+	Innermost
+)
+
+// ChainAttrs returns the attributes, including the Prefix, of every *Error and
+// Multi in the chain of err merged by key. Errors that wrap several errors,
+// such as a Multi, are walked depth first and each error counts as outer to
+// those found after it. When the same key is found more than once the value
+// is chosen by p. Groups with the same key are merged member by member.
+// Sensitive attributes are redacted, see SetRedaction.
+func ChainAttrs(err error, p Precedence) []slog.Attr {
+	// the prefix and attributes of each error.
+	var chain [][2][]slog.Attr
+	for curr := range tree(err) {
+		switch ee := curr.(type) {
+		case *Error:
+			chain = append(chain, [2][]slog.Attr{ee.Prefix, ee.Attrs})
+		case *Multi:
+			chain = append(chain, [2][]slog.Attr{nil, ee.Attrs})
+		}
+	}
+	if p == Innermost {
+		slices.Reverse(chain)
+	}
+	var out []slog.Attr
+	for _, level := range chain {
+		out = mergeAttrs(out, renderAttrs(level[0]))
+		out = mergeAttrs(out, renderAttrs(level[1]))
+	}
+	return out
+}
+
+// LookupAttr returns the value of the attribute with the specified key merged
+// across the chain of err. Keys of nested groups are separated by dots.
+func LookupAttr(err error, key string, p Precedence) (slog.Value, bool) {
+	return lookup(ChainAttrs(err, p), key)
+}
+
+func lookup(attrs []slog.Attr, key string) (slog.Value, bool) {
+	for _, a := range attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+		if rest, ok := strings.CutPrefix(key, a.Key+"."); ok && a.Value.Kind() == slog.KindGroup {
+			if v, ok := lookup(a.Value.Group(), rest); ok {
+				return v, true
+			}
+		}
+	}
+	return slog.Value{}, false
+}
+
+// mergeAttrs adds the attributes in src that are not already present in dst.
+// Groups present in both are merged recursively.
+func mergeAttrs(dst, src []slog.Attr) []slog.Attr {
+	for _, a := range src {
+		i := slices.IndexFunc(dst, func(d slog.Attr) bool { return d.Key == a.Key })
+		switch {
+		case i < 0:
+			dst = append(dst, a)
+		case dst[i].Value.Kind() == slog.KindGroup && a.Value.Kind() == slog.KindGroup:
+			members := mergeAttrs(slices.Clone(dst[i].Value.Group()), a.Value.Group())
+			dst[i] = slog.Attr{Key: a.Key, Value: slog.GroupValue(members...)}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,94 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"github.com/cyrusaf/ctxlog"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestAttrs(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(), slog.Int("count", 3))
+	err := E(Op("op"), ctx, "msg", slog.Duration("wait", time.Second), "user", "bob", "dangling")
+
+	e := err.(*Error)
+	if e.Msg != "msg" {
+		t.Errorf("E() got Msg %q wanted %q", e.Msg, "msg")
+	}
+	want := []slog.Attr{
+		slog.Int("count", 3),
+		slog.Duration("wait", time.Second),
+		slog.String("user", "bob"),
+		slog.String(badKey, "dangling"),
+	}
+	if diff := cmp.Diff(want, e.Attrs, cmpAttrs); diff != "" {
+		t.Errorf("E() got Attrs diff -want/+got: %s", diff)
+	}
+}
+
+func TestAttrsKeyBeforeCause(t *testing.T) {
+	e := E(Op("x"), "msg", "retrying", io.EOF).(*Error)
+	if !errors.Is(e, io.EOF) {
+		t.Errorf("errors.Is(io.EOF) got false wanted true")
+	}
+	want := []slog.Attr{slog.String(badKey, "retrying")}
+	if diff := cmp.Diff(want, e.Attrs, cmpAttrs); diff != "" {
+		t.Errorf("E() got Attrs diff -want/+got: %s", diff)
+	}
+}
+
+func TestChainAttrs(t *testing.T) {
+	inner := E(Op("inner"), errors.New("concrete"),
+		slog.String("who", "inner"),
+		slog.Group("req", slog.String("id", "abc"), slog.Int("try", 1)))
+	outer := E(Op("outer"), inner,
+		slog.String("who", "outer"),
+		slog.Group("req", slog.Int("try", 2)))
+
+	cases := []struct {
+		name string
+		p    Precedence
+		want []slog.Attr
+	}{
+		{
+			name: "Outermost",
+			p:    Outermost,
+			want: []slog.Attr{
+				slog.String("who", "outer"),
+				slog.Group("req", slog.Int("try", 2), slog.String("id", "abc")),
+			},
+		},
+		{
+			name: "Innermost",
+			p:    Innermost,
+			want: []slog.Attr{
+				slog.String("who", "inner"),
+				slog.Group("req", slog.String("id", "abc"), slog.Int("try", 1)),
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ChainAttrs(outer, tc.p)
+			if diff := cmp.Diff(tc.want, got, cmpAttrs); diff != "" {
+				t.Errorf("ChainAttrs() got diff -want/+got: %s", diff)
+			}
+		})
+	}
+
+	t.Run("LookupAttr", func(t *testing.T) {
+		v, ok := LookupAttr(outer, "req.id", Outermost)
+		if !ok || v.String() != "abc" {
+			t.Errorf("LookupAttr() got (%v, %t) wanted (%q, true)", v, ok, "abc")
+		}
+		if _, ok := LookupAttr(outer, "missing", Outermost); ok {
+			t.Errorf("LookupAttr() got found for missing key")
+		}
+	})
+}
+
+var cmpAttrs = cmp.Comparer(func(a, b slog.Attr) bool { return a.Equal(b) })
@@ -0,0 +1,112 @@
+package ctxerr
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync/atomic"
+)
+
+// CaptureMode selects when E() captures a stack.
+type CaptureMode int
+
+const (
+	// CaptureAlways captures a stack for every error without an *Error in
+	// its cause chain. This is the default.
+	CaptureAlways CaptureMode = iota
+	// CaptureNever never captures a stack.
+	CaptureNever
+	// CaptureKinds captures a stack only for errors of the Kinds listed in
+	// CapturePolicy.Kinds.
+	CaptureKinds
+	// CaptureLite captures neither a stack nor context attributes, leaving
+	// only the Op, Kind, message and cause. Used on hot paths.
+	CaptureLite
+	// CaptureLevel captures a stack only for errors whose Kind.Level() is at
+	// least CapturePolicy.Level.
+	CaptureLevel
+)
+
+// defaultMaxDepth is the number of PCs captured when MaxDepth is not set.
+const defaultMaxDepth = 64
+
+// CapturePolicy controls the cost of creating an *Error. The policy set with
+// SetCapturePolicy applies to all calls to E(), and a CapturePolicy passed as
+// an argument to E() applies to that call only.
+type CapturePolicy struct {
+	Mode CaptureMode
+	// Kinds lists the Kinds captured when Mode is CaptureKinds.
+	Kinds []Kind
+	// Level is the minimum level captured when Mode is CaptureLevel, such
+	// as slog.LevelError to capture only failures of the server.
+	Level slog.Level
+	// SampleRate is the fraction of eligible errors whose stack is captured.
+	// Zero or values of one or more capture every stack.
+	SampleRate float64
+	// Rand returns the number in [0, 1) compared with SampleRate. It
+	// defaults to rand.Float64 and is set for a reproducible sample.
+	Rand func() float64
+	// MaxDepth is the maximum number of frames captured, 64 if zero.
+	MaxDepth int
+
+	// Goroutine, Labels and Time record the Origin of each error: the ID
+	// of the goroutine creating it, the pprof labels of its context and
+	// when it was created. They are off by default for their cost, and are
+	// not recorded in CaptureLite mode.
+	Goroutine bool
+	Labels    bool
+	Time      bool
+}
+
+// Common per-call policies.
+var (
+	NoStack = CapturePolicy{Mode: CaptureNever}
+	Lite    = CapturePolicy{Mode: CaptureLite}
+)
+
+var capturePolicy atomic.Pointer[CapturePolicy]
+
+// SetCapturePolicy sets the policy used by E() when no CapturePolicy argument
+// is passed.
+func SetCapturePolicy(p CapturePolicy) {
+	capturePolicy.Store(&p)
+}
+
+func currentCapturePolicy() CapturePolicy {
+	if p := capturePolicy.Load(); p != nil {
+		return *p
+	}
+	return CapturePolicy{}
+}
+
+// captureStack reports whether a stack should be captured for an error of
+// the specified Kind.
+func (p CapturePolicy) captureStack(k Kind) bool {
+	switch p.Mode {
+	case CaptureNever, CaptureLite:
+		return false
+	case CaptureKinds:
+		if !slices.Contains(p.Kinds, k) {
+			return false
+		}
+	case CaptureLevel:
+		if k.Level() < p.Level {
+			return false
+		}
+	}
+	if p.SampleRate > 0 && p.SampleRate < 1 {
+		random := rand.Float64
+		if p.Rand != nil {
+			random = p.Rand
+		}
+		return random() < p.SampleRate
+	}
+	return true
+}
+
+func (p CapturePolicy) maxDepth() int {
+	if p.MaxDepth > 0 {
+		return p.MaxDepth
+	}
+	return defaultMaxDepth
+}
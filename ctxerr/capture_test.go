@@ -0,0 +1,85 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"github.com/cyrusaf/ctxlog"
+	"log/slog"
+	"slices"
+	"testing"
+)
+
+func TestCapturePolicy(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(), slog.String("call", "lookup"))
+	cause := errors.New("cause")
+
+	cases := []struct {
+		name      string
+		global    CapturePolicy
+		args      []any
+		wantStack bool
+		wantAttrs bool
+	}{
+		{"Default", CapturePolicy{}, nil, true, true},
+		{"Never", NoStack, nil, false, true},
+		{"PerCall", CapturePolicy{}, []any{NoStack}, false, true},
+		{"PerCallOverridesGlobal", NoStack, []any{CapturePolicy{}}, true, true},
+		{"Lite", CapturePolicy{}, []any{Lite}, false, false},
+		{"KindMatch", CapturePolicy{Mode: CaptureKinds, Kinds: []Kind{Internal}}, []any{Internal}, true, true},
+		{"KindMismatch", CapturePolicy{Mode: CaptureKinds, Kinds: []Kind{Internal}}, []any{Invalid}, false, true},
+		{"LevelMatch", CapturePolicy{Mode: CaptureLevel, Level: slog.LevelError}, []any{Unavailable}, true, true},
+		{"LevelMismatch", CapturePolicy{Mode: CaptureLevel, Level: slog.LevelError}, []any{NotFound}, false, true},
+		{"LevelDefault", CapturePolicy{Mode: CaptureLevel}, []any{NotFound}, true, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			SetCapturePolicy(tc.global)
+			defer SetCapturePolicy(CapturePolicy{})
+
+			err := E(append([]any{Op("op"), ctx, cause}, tc.args...)...).(*Error)
+			if got := err.hasStack(); got != tc.wantStack {
+				t.Errorf("E() got stack=%t wanted %t", got, tc.wantStack)
+			}
+			if got := len(err.Attrs) > 0; got != tc.wantAttrs {
+				t.Errorf("E() got attrs=%t wanted %t", got, tc.wantAttrs)
+			}
+			if err.Op != "op" || err.Err != cause {
+				t.Errorf("E() got Op %q Err %v wanted %q %v", err.Op, err.Err, "op", cause)
+			}
+		})
+	}
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		err := E(Op("op"), CapturePolicy{MaxDepth: 2}).(*Error)
+		if n := len(err.callers); n != 2 {
+			t.Errorf("E() got %d callers wanted 2", n)
+		}
+	})
+	t.Run("SampleRate", func(t *testing.T) {
+		p := CapturePolicy{SampleRate: 0.5}
+		var n int
+		for i := 0; i < 1000; i++ {
+			if E(Op("op"), p).(*Error).hasStack() {
+				n++
+			}
+		}
+		if n == 0 || n == 1000 {
+			t.Errorf("E() captured %d of 1000 stacks with SampleRate 0.5", n)
+		}
+	})
+	t.Run("Rand", func(t *testing.T) {
+		values := []float64{0.1, 0.9, 0.4, 0.6}
+		p := CapturePolicy{SampleRate: 0.5, Rand: func() float64 {
+			v := values[0]
+			values = values[1:]
+			return v
+		}}
+		var got []bool
+		for range 4 {
+			got = append(got, E(Op("op"), p).(*Error).hasStack())
+		}
+		if want := []bool{true, false, true, false}; !slices.Equal(got, want) {
+			t.Errorf("E() got stacks %v wanted %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,12 @@
+// Command ctxerrcheck runs the ctxerrcheck analyzer through go vet:
+//
+//	go install github.com/nveeser/srvsrv/ctxerr/ctxerrcheck/cmd/ctxerrcheck
+//	go vet -vettool=$(which ctxerrcheck) ./...
+package main
+
+import (
+	"github.com/nveeser/srvsrv/ctxerr/ctxerrcheck"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() { unitchecker.Main(ctxerrcheck.Analyzer) }
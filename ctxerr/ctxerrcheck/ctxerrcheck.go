@@ -0,0 +1,339 @@
+// Package ctxerrcheck defines an Analyzer that reports misuse of the ctxerr
+// package that would otherwise only show up at runtime.
+//
+// It reports:
+//
+//   - arguments to E() and Ef() of a type that E() does not interpret
+//   - nil passed as the cause of an error
+//   - an error passed as the value of a key/value pair, which E() reads as
+//     the cause instead
+//   - format strings of Ef(), Errorf() and WithMsgf() that do not match their
+//     arguments
+//   - errors created without an Op in an exported function
+//   - errors created without a cause or the context.Context of the enclosing
+//     function
+package ctxerrcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+	"strings"
+)
+
+const ctxerrPath = "github.com/nveeser/srvsrv/ctxerr"
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "ctxerrcheck",
+	Doc:      "check for misuse of ctxerr.E, ctxerr.Ef, ctxerr.New and ctxerr.Errorf",
+	URL:      "https://pkg.go.dev/github.com/nveeser/srvsrv/ctxerr/ctxerrcheck",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	filter := []ast.Node{(*ast.CallExpr)(nil)}
+	insp.WithStack(filter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		call := n.(*ast.CallExpr)
+		fn := ctxerrFunc(pass, call)
+		if fn == nil {
+			return true
+		}
+		c := &checker{pass: pass, call: call, name: fn.Name()}
+		switch fn.Name() {
+		case "E":
+			c.checkE(call.Args)
+		case "Ef":
+			c.checkEf()
+		case "New":
+			c.checkNew()
+		case "Errorf":
+			if len(call.Args) >= 2 {
+				c.checkFormat(call.Args[1], call.Args[2:])
+			}
+			return true
+		case "WithMsgf":
+			if len(call.Args) >= 1 {
+				c.checkFormat(call.Args[0], call.Args[1:])
+			}
+			return true
+		case "WithCause":
+			if len(call.Args) == 1 && isNil(pass, call.Args[0]) {
+				pass.Reportf(call.Args[0].Pos(), "nil cause passed to ctxerr.WithCause")
+			}
+			return true
+		default:
+			return true
+		}
+		if call.Ellipsis.IsValid() || c.unknown {
+			return true
+		}
+		c.checkEnclosing(stack)
+		return true
+	})
+	return nil, nil
+}
+
+// ctxerrFunc returns the function or method of the ctxerr package called by
+// call, or nil.
+func ctxerrFunc(pass *analysis.Pass, call *ast.CallExpr) *types.Func {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != ctxerrPath {
+		return nil
+	}
+	return fn
+}
+
+type checker struct {
+	pass *analysis.Pass
+	call *ast.CallExpr
+	name string
+
+	hasOp, hasCtx, hasCause bool
+	// unknown is set when the arguments cannot be fully inspected, such as
+	// options held in variables.
+	unknown bool
+}
+
+// checkE checks the arguments of E(), or those before the format of Ef().
+func (c *checker) checkE(args []ast.Expr) {
+	if c.call.Ellipsis.IsValid() {
+		return
+	}
+	var hasMsg bool
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if isNil(c.pass, arg) {
+			c.pass.Reportf(arg.Pos(), "nil cause passed to ctxerr.%s", c.name)
+			continue
+		}
+		t := c.pass.TypesInfo.TypeOf(arg)
+		if t == nil {
+			continue
+		}
+		switch {
+		case isCtxerr(t, "Op"), isCtxerr(t, "autoOp"):
+			c.hasOp = true
+		case isCtxerr(t, "Kind"), isCtxerr(t, "temporary"), isCtxerr(t, "CapturePolicy"):
+		case isContext(t):
+			c.hasCtx = true
+		case isAttr(t), isAttrSlice(t):
+		case types.Implements(t, errorType):
+			c.hasCause = true
+		case isString(t):
+			if !hasMsg {
+				hasMsg = true
+				continue
+			}
+			// key/value pair
+			if i+1 >= len(args) {
+				c.pass.Reportf(arg.Pos(), "ctxerr.%s key %s has no value", c.name, render(arg))
+				continue
+			}
+			// E() never takes an error as a value, so it is read as the cause.
+			if c.isCause(args[i+1]) {
+				c.pass.Reportf(arg.Pos(), "ctxerr.%s key %s has no value: the error after it is the cause", c.name, render(arg))
+				continue
+			}
+			i++
+		case types.IsInterface(t):
+			// The dynamic type may be supported.
+			c.unknown = true
+		default:
+			c.pass.Reportf(arg.Pos(), "ctxerr.%s does not accept an argument of type %s", c.name, t)
+		}
+	}
+}
+
+// isCause reports whether E() reads the argument as the cause of the error.
+func (c *checker) isCause(arg ast.Expr) bool {
+	if isNil(c.pass, arg) {
+		return true
+	}
+	t := c.pass.TypesInfo.TypeOf(arg)
+	return t != nil && types.Implements(t, errorType)
+}
+
+func (c *checker) checkEf() {
+	args := c.call.Args
+	idx := -1
+	for i, arg := range args {
+		if t := c.pass.TypesInfo.TypeOf(arg); t != nil && isString(t) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		c.checkE(args)
+		return
+	}
+	c.checkE(args[:idx])
+	if !c.call.Ellipsis.IsValid() {
+		c.checkFormat(args[idx], args[idx+1:])
+	}
+}
+
+func (c *checker) checkNew() {
+	if c.call.Ellipsis.IsValid() {
+		return
+	}
+	for _, arg := range c.call.Args {
+		if isNil(c.pass, arg) {
+			continue
+		}
+		opt, ok := arg.(*ast.CallExpr)
+		if !ok {
+			c.unknown = true
+			continue
+		}
+		fn := ctxerrFunc(c.pass, opt)
+		if fn == nil {
+			c.unknown = true
+			continue
+		}
+		switch fn.Name() {
+		case "WithOp", "WithAutoOp":
+			c.hasOp = true
+		case "WithContext":
+			c.hasCtx = true
+		case "WithCause":
+			c.hasCause = true
+		}
+	}
+}
+
+// checkFormat reports a format string that does not match its arguments.
+func (c *checker) checkFormat(format ast.Expr, args []ast.Expr) {
+	tv, ok := c.pass.TypesInfo.Types[format]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String || c.call.Ellipsis.IsValid() {
+		return
+	}
+	want := countArgs(constant.StringVal(tv.Value))
+	if want < 0 || want == len(args) {
+		return
+	}
+	c.pass.Reportf(format.Pos(), "ctxerr.%s format %s reads %d args, but call has %d args",
+		c.name, render(format), want, len(args))
+}
+
+// checkEnclosing reports a call missing the Op or context expected from the
+// function it appears in. Tests are not required to set an Op, and a call
+// wrapping a cause is not required to pass the context, which was available to
+// the function that returned the cause.
+func (c *checker) checkEnclosing(stack []ast.Node) {
+	file := c.pass.Fset.File(c.call.Pos()).Name()
+	if !c.hasOp && !strings.HasSuffix(file, "_test.go") {
+		for _, n := range stack {
+			if decl, ok := n.(*ast.FuncDecl); ok && decl.Name.IsExported() {
+				c.pass.Reportf(c.call.Pos(), "ctxerr.%s in exported function %s has no Op", c.name, decl.Name.Name)
+				break
+			}
+		}
+	}
+	if !c.hasCtx && !c.hasCause {
+		if name := c.contextParam(stack); name != "" {
+			c.pass.Reportf(c.call.Pos(), "%s is not passed to ctxerr.%s", name, c.name)
+		}
+	}
+}
+
+// contextParam returns the name of the context.Context parameter of the
+// innermost function enclosing the call, if any.
+func (c *checker) contextParam(stack []ast.Node) string {
+	for i := len(stack) - 1; i >= 0; i-- {
+		var ftype *ast.FuncType
+		switch n := stack[i].(type) {
+		case *ast.FuncDecl:
+			ftype = n.Type
+		case *ast.FuncLit:
+			ftype = n.Type
+		default:
+			continue
+		}
+		for _, field := range ftype.Params.List {
+			t := c.pass.TypesInfo.TypeOf(field.Type)
+			if t == nil || !isContext(t) {
+				continue
+			}
+			for _, name := range field.Names {
+				if name.Name != "_" {
+					return name.Name
+				}
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// countArgs returns the number of arguments read by the format, or -1 if it
+// uses explicit argument indexes.
+func countArgs(format string) int {
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		// Skip the flags, width and precision.
+		for i++; i < len(format) && strings.IndexByte("+-# 0123456789.*[", format[i]) >= 0; i++ {
+			switch format[i] {
+			case '[':
+				return -1
+			case '*':
+				n++
+			}
+		}
+		if i < len(format) && format[i] != '%' {
+			n++
+		}
+	}
+	return n
+}
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+func isNil(pass *analysis.Pass, e ast.Expr) bool {
+	tv, ok := pass.TypesInfo.Types[e]
+	return ok && tv.IsNil()
+}
+
+func isNamed(t types.Type, pkg, name string) bool {
+	n, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := n.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkg && obj.Name() == name
+}
+
+func isCtxerr(t types.Type, name string) bool { return isNamed(t, ctxerrPath, name) }
+func isContext(t types.Type) bool             { return isNamed(t, "context", "Context") }
+func isAttr(t types.Type) bool                { return isNamed(t, "log/slog", "Attr") }
+
+func isAttrSlice(t types.Type) bool {
+	s, ok := types.Unalias(t).(*types.Slice)
+	return ok && isAttr(s.Elem())
+}
+
+func isString(t types.Type) bool {
+	b, ok := types.Unalias(t).(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+func render(e ast.Expr) string {
+	if lit, ok := e.(*ast.BasicLit); ok {
+		return lit.Value
+	}
+	if id, ok := e.(*ast.Ident); ok {
+		return id.Name
+	}
+	return "string"
+}
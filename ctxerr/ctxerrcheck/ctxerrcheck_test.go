@@ -0,0 +1,31 @@
+package ctxerrcheck
+
+import (
+	"golang.org/x/tools/go/analysis/analysistest"
+	"testing"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
+
+func TestCountArgs(t *testing.T) {
+	cases := []struct {
+		format string
+		want   int
+	}{
+		{"plain", 0},
+		{"%d %s", 2},
+		{"100%%", 0},
+		{"%-8.3f", 1},
+		{"%*d", 2},
+		{"%.*f", 2},
+		{"%[2]d %[1]d", -1},
+		{"trailing %", 0},
+	}
+	for _, tc := range cases {
+		if got := countArgs(tc.format); got != tc.want {
+			t.Errorf("countArgs(%q) got %d wanted %d", tc.format, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package a
+
+import (
+	"context"
+	"errors"
+	"github.com/nveeser/srvsrv/ctxerr"
+	"log/slog"
+)
+
+type key string
+
+var errBase = errors.New("base")
+
+func args(ctx context.Context) {
+	_ = ctxerr.E(ctxerr.Op("op"), ctx, ctxerr.NotFound, ctxerr.Temporary, ctxerr.NoStack, "msg", "k", 1, slog.Int("n", 1), []slog.Attr{}, errBase)
+	_ = ctxerr.E(ctxerr.Op("op"), ctx, 42)                         // want `ctxerr.E does not accept an argument of type int`
+	_ = ctxerr.E(ctxerr.Op("op"), ctx, key("k"))                   // want `ctxerr.E does not accept an argument of type a.key`
+	_ = ctxerr.E(ctxerr.Op("op"), ctx, "msg", "dangle")            // want `ctxerr.E key "dangle" has no value`
+	_ = ctxerr.E(ctxerr.Op("op"), ctx, nil)                        // want `nil cause passed to ctxerr.E`
+	_ = ctxerr.E(ctxerr.Op("op"), ctx, "msg", "retrying", errBase) // want `ctxerr.E key "retrying" has no value: the error after it is the cause`
+	_ = ctxerr.E(ctxerr.Op("op"), ctx, "msg", "attempt", nil)      // want `ctxerr.E key "attempt" has no value: the error after it is the cause` `nil cause passed to ctxerr.E`
+	_ = ctxerr.New(ctxerr.WithOp("op"), ctxerr.WithCause(nil))     // want `nil cause passed to ctxerr.WithCause`
+
+	var v any
+	_ = ctxerr.E(ctxerr.Op("op"), ctx, v)
+	var list []any
+	_ = ctxerr.E(list...)
+}
+
+func formats(ctx context.Context) {
+	_ = ctxerr.Ef(ctxerr.Op("op"), ctx, "read %d of %s", 1, "f")
+	_ = ctxerr.Ef(ctxerr.Op("op"), ctx, "read %d of %s", 1)         // want `ctxerr.Ef format "read %d of %s" reads 2 args, but call has 1 args`
+	_ = ctxerr.Ef(ctxerr.Op("op"), ctx, "100%% %*d %[1]d", 1, 2, 3) // explicit indexes are not checked
+	_ = ctxerr.Ef(ctxerr.Op("op"), ctx, "%*d", 1, 2)
+	_ = ctxerr.Errorf(ctx, "%s", "a")
+	_ = ctxerr.Errorf(ctx, "%s %s", "a")                                  // want `ctxerr.Errorf format "%s %s" reads 2 args, but call has 1 args`
+	_ = ctxerr.Prefix{}.Errorf(ctx, "plain", "a")                         // want `ctxerr.Errorf format "plain" reads 0 args, but call has 1 args`
+	_ = ctxerr.New(ctxerr.WithOp("op"), ctx2(ctx), ctxerr.WithMsgf("%d")) // want `ctxerr.WithMsgf format "%d" reads 1 args, but call has 0 args`
+}
+
+func ctx2(ctx context.Context) ctxerr.Option { return ctxerr.WithContext(ctx) }
+
+func Exported(ctx context.Context) error {
+	if ctx == nil {
+		return ctxerr.E(ctx, "no op") // want `ctxerr.E in exported function Exported has no Op`
+	}
+	if ctx.Err() != nil {
+		return ctxerr.New(ctxerr.WithContext(ctx), ctxerr.WithMsgf("no op")) // want `ctxerr.New in exported function Exported has no Op`
+	}
+	if ctx.Value(key("k")) != nil {
+		return ctxerr.New(ctxerr.WithAutoOp(), ctxerr.WithContext(ctx))
+	}
+	if ctx.Value(key("j")) != nil {
+		return ctxerr.E(ctxerr.AutoOp, ctx, "auto")
+	}
+	return ctxerr.E(ctxerr.Op("Exported"), ctx, "ok")
+}
+
+func dropped(ctx context.Context) error {
+	if ctx == nil {
+		return ctxerr.E(ctxerr.Op("dropped"), "msg") // want `ctx is not passed to ctxerr.E`
+	}
+	if ctx.Err() != nil {
+		return ctxerr.New(ctxerr.WithOp("dropped")) // want `ctx is not passed to ctxerr.New`
+	}
+	f := func(_ context.Context) error {
+		return ctxerr.E(ctxerr.Op("dropped"), "unnamed context")
+	}
+	g := func(inner context.Context) error {
+		return ctxerr.E(ctxerr.Op("dropped"), "msg") // want `inner is not passed to ctxerr.E`
+	}
+	_, _ = f, g
+	// Wrapping a cause does not require the context.
+	return ctxerr.E(ctxerr.Op("dropped"), errBase)
+}
@@ -0,0 +1,48 @@
+// Package ctxerr is a stub of the real package for the analyzer tests.
+package ctxerr
+
+import (
+	"context"
+	"log/slog"
+)
+
+type Op string
+
+type autoOp bool
+
+const AutoOp = autoOp(true)
+
+type Kind uint8
+
+const NotFound Kind = 2
+
+type temporary bool
+
+const Temporary = temporary(true)
+
+type CapturePolicy struct{}
+
+var NoStack = CapturePolicy{}
+
+type Error struct{}
+
+func (e *Error) Error() string { return "" }
+
+func E(args ...any) error  { return nil }
+func Ef(args ...any) error { return nil }
+
+func Errorf(ctx context.Context, format string, args ...any) error { return nil }
+
+type Prefix []string
+
+func (p Prefix) Errorf(ctx context.Context, format string, args ...any) error { return nil }
+
+type Option func()
+
+func New(opts ...Option) error                   { return nil }
+func WithOp(op Op) Option                        { return nil }
+func WithAutoOp() Option                         { return nil }
+func WithMsgf(format string, args ...any) Option { return nil }
+func WithCause(err error) Option                 { return nil }
+func WithContext(ctx context.Context) Option     { return nil }
+func WithAttrs(attrs ...slog.Attr) Option        { return nil }
@@ -10,51 +10,66 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/cyrusaf/ctxlog"
 	"io"
-	"log"
+	"iter"
 	"log/slog"
-	"reflect"
-	"runtime"
 	"slices"
 	"strconv"
 	"strings"
 )
 
-var ErrorPrefixKeys = []string{
-	"module",
-	"call",
-	"action",
-	"error",
-}
-
 type Op string
 
 type Error struct {
 	Op    Op
+	Kind  Kind
 	Msg   string
 	Err   error
-	Attrs map[string]string
+	Attrs []slog.Attr
+	// Prefix holds context attributes rendered before the Op and message,
+	// see ContextError and Errorf.
+	Prefix []slog.Attr
+	// Temporary marks the error as safe to retry, see Retry.
+	Temporary bool
+	// Origin records where and when the error was created, if enabled by
+	// the CapturePolicy.
+	Origin *Origin
 	stack
 }
 
+// E returns a new *Error built from the arguments. Each argument is
+// interpreted by type:
+//
+//   - Op sets the operation, or AutoOp sets it to the name of the caller
+//   - Kind sets the classification of the error
+//   - Temporary marks the error as safe to retry
+//   - string sets the message, the first time
+//   - error (or *Error) sets the cause
+//   - context.Context adds the attributes of the context, see AttrExtractor
+//   - slog.Attr or []slog.Attr adds the attributes
+//   - CapturePolicy overrides the stack capture policy for this call
+//
+// Once the message is set, a string argument is a key followed by its value,
+// as with the arguments to slog.Logger.Info. An error is never read as a
+// value: a key followed by an error is kept with the key "!BADKEY" and the
+// error is the cause. A nil argument is ignored, and an argument of any other
+// type is kept as an attribute with the key "!BADKEY".
+// The ctxerrcheck analyzer reports such calls at compile time.
+//
+// New builds an *Error from typed options instead.
 func E(args ...any) error {
 	e := newError(args...)
 	return e
 }
 
-// Tests:
-// 0 args
-// 1 args
-// 2 args
-// no string
-
+// Ef is like E, but the first string argument is a format string and all of
+// the arguments after it are passed to fmt.Sprintf to create the message.
 func Ef(args ...any) error {
 	idx := slices.IndexFunc(args, func(v any) bool {
 		_, ok := v.(string)
 		return ok
 	})
-	if idx > 0 {
+	if idx >= 0 {
 		var fmtArgs []any
 		args, fmtArgs = args[:idx], args[idx:]
 		msg := fmtArgs[0].(string)
@@ -68,42 +83,108 @@ func Ef(args ...any) error {
 }
 
 func newError(args ...any) *Error {
+	e, policy := buildError(args)
+	e.populateStack(policy)
+	return e
+}
+
+// buildError sets the fields of a new *Error from the arguments to E(), and
+// returns the CapturePolicy for the stack, which the caller captures.
+func buildError(args []any) (*Error, CapturePolicy) {
 	e := &Error{}
+	policy := currentCapturePolicy()
 	for _, arg := range args {
-		switch arg := arg.(type) {
+		if p, ok := arg.(CapturePolicy); ok {
+			policy = p
+		}
+	}
+	var hasMsg, auto bool
+	var ctx context.Context
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i].(type) {
+		case CapturePolicy:
+			// handled above
+
+		case autoOp:
+			auto = bool(arg)
+
 		case Op:
 			e.Op = arg
 
+		case Kind:
+			e.Kind = arg
+
+		case temporary:
+			e.Temporary = bool(arg)
+
 		case *Error:
+			if arg == nil {
+				continue
+			}
 			// Make a copy
 			copyArg := *arg
 			e.Err = &copyArg
 
 		case context.Context:
-			v := ctxlog.GetAttrs(arg)
-			e.Attrs = make(map[string]string)
-			for _, attr := range v {
-				e.Attrs[attr.Key] = attr.Value.String()
+			if ctx == nil {
+				ctx = arg
 			}
+			if policy.Mode != CaptureLite {
+				e.Attrs = append(e.Attrs, ContextAttrs(arg)...)
+			}
+
+		case slog.Attr:
+			e.Attrs = append(e.Attrs, arg)
+
+		case []slog.Attr:
+			e.Attrs = append(e.Attrs, arg...)
+
+		case nil:
+			// A nil cause is ignored, so E(op, err) is safe when err is nil.
 
 		case error:
-			if arg == nil {
-				panic("nil error passed to E()")
-			}
 			e.Err = arg
+
 		case string:
-			e.Msg = arg
+			if !hasMsg {
+				e.Msg, hasMsg = arg, true
+				continue
+			}
+			// key/value pair, but an error is never taken as a value so
+			// the cause is not lost, as in E(op, "msg", "retrying", err).
+			if i+1 >= len(args) || isCause(args[i+1]) {
+				e.Attrs = append(e.Attrs, slog.String(badKey, arg))
+				continue
+			}
+			i++
+			e.Attrs = append(e.Attrs, slog.Any(arg, args[i]))
 
 		default:
-			_, file, line, _ := runtime.Caller(1)
-			log.Printf("errors.E: bad call from %s:%d: %v", file, line, args)
-			panic("E() called with unknown type" + reflect.TypeOf(arg).String())
+			// Keep the value rather than panic, as slog does.
+			e.Attrs = append(e.Attrs, slog.Any(badKey, arg))
 		}
 	}
-	e.populateStack()
-	return e
+	if auto && e.Op == "" {
+		// skip buildError, newError and E() or Ef()
+		e.Op = callerOp(3)
+	}
+	e.redactCaptured()
+	e.captureOrigin(ctx, policy)
+	return e, policy
+}
+
+// isCause reports whether the argument is read as the cause of the error.
+func isCause(arg any) bool {
+	if arg == nil {
+		return true
+	}
+	_, ok := arg.(error)
+	return ok
 }
 
+// badKey is the key used for a dangling key without a value, matching slog.
+const badKey = "!BADKEY"
+
 func (e *Error) Unwrap() error { return e.Err }
 
 func (e *Error) isZero() bool {
@@ -112,53 +193,83 @@ func (e *Error) isZero() bool {
 
 func (e *Error) Error() string {
 	var b strings.Builder
-	e.writeSummary(&b, true)
+	Rendering.s()(&b, e)
 	return b.String()
 }
 
+// Format renders the error with the Renderer selected for the verb by
+// Rendering. %q quotes the result of Error().
 func (e *Error) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'w', 's':
-		e.writeSummary(s, true)
+		Rendering.s()(s, e)
+
+	case 'q':
+		io.WriteString(s, strconv.Quote(e.Error()))
 
 	case 'v':
-		var curr error = e
-		var stacked *Error = e
-		var written bool
-		for curr != nil {
-			if written {
-				io.WriteString(s, "\n")
-			}
-			if ee, ok := curr.(*Error); ok {
-				ee.writeSummary(s, false)
-				stacked = ee
-			} else {
-				io.WriteString(s, curr.Error())
-			}
-			written = true
-			curr = errors.Unwrap(curr)
+		if s.Flag('+') {
+			Rendering.plusV()(s, e)
+		} else {
+			Rendering.v()(s, e)
 		}
-		stacked.walkStack(3, func(file string, line int, fname string) {
-			if written {
-				io.WriteString(s, "\n\t")
-			}
-			writeCallsite(s, file, line)
-			io.WriteString(s, " \n\t   ")
-			io.WriteString(s, fname)
-			io.WriteString(s, "(...)")
-			written = true
-		})
 	}
 }
 
-var writeCallsite = func(w io.Writer, file string, line int) {
+// writeChain writes the summary of each error in the chain on its own line
+// followed by the stack of the innermost *Error.
+func (e *Error) writeChain(w io.Writer) {
+	var curr error = e
+	var stacked *Error = e
+	var written bool
+	for curr != nil {
+		if written {
+			io.WriteString(w, "\n")
+		}
+		if ee, ok := curr.(*Error); ok {
+			ee.writeSummary(w, false)
+			stacked = ee
+		} else {
+			io.WriteString(w, curr.Error())
+		}
+		written = true
+		curr = errors.Unwrap(curr)
+	}
+	stacked.writeStack(w, written)
+}
+
+// writeStack writes the stack of e, starting on a new line if written is set.
+func (e *Error) writeStack(w io.Writer, written bool) {
+	e.walkStack(5, func(file string, line int, fname string) {
+		if written {
+			io.WriteString(w, "\n\t")
+		}
+		writeCallsite(w, file, line)
+		io.WriteString(w, " \n\t   ")
+		io.WriteString(w, fname)
+		io.WriteString(w, "(...)")
+		written = true
+	})
+}
+
+// writeCallsite writes "file:line", or only the file if the line is unknown.
+func writeCallsite(w io.Writer, file string, line int) {
 	w.Write([]byte(file))
+	if line == 0 {
+		return
+	}
 	w.Write([]byte(":"))
 	w.Write(strconv.AppendInt(nil, int64(line), 10))
 }
 
 func (e *Error) writeSummary(w io.Writer, withCause bool) {
 	var written bool
+	for _, attr := range renderAttrs(e.Prefix) {
+		io.WriteString(w, "[")
+		io.WriteString(w, attr.Value.Resolve().String())
+		io.WriteString(w, "]")
+		written = true
+	}
 	if e.Op != "" {
 		io.WriteString(w, "[")
 		io.WriteString(w, string(e.Op))
@@ -184,15 +295,6 @@ func (e *Error) writeSummary(w io.Writer, withCause bool) {
 	}
 }
 
-func ContextError(ctx context.Context, err error) error {
-	var format strings.Builder
-	args := buildPrefixFormat(&format, ctxlog.GetAttrs(ctx))
-
-	args = append(args, err)
-	format.WriteString(": %w")
-	return fmt.Errorf(format.String(), args)
-}
-
 // errorString is a trivial implementation of error.
 type errorString struct {
 	s string
@@ -202,29 +304,31 @@ func (e *errorString) Error() string {
 	return e.s
 }
 
-// Errorf returns a new error adding attributes from the context
-// using ctxerr.
-func Errorf(ctx context.Context, format string, args ...any) error {
-	var prefix strings.Builder
-	xargs := buildPrefixFormat(&prefix, ctxlog.GetAttrs(ctx))
-	prefix.WriteString(format)
-	format = prefix.String()
-	args = append(xargs, args...)
-	return fmt.Errorf(format, args...)
-}
-
-func buildPrefixFormat(b *strings.Builder, attrs []slog.Attr) []any {
-	var args []any
-	for _, attr := range attrs {
-		if slices.Contains(ErrorPrefixKeys, attr.Key) {
-			switch attr.Value.Any().(type) {
-			case string, fmt.Stringer:
-				b.WriteString("[%s]")
-			default:
-				panic("context key is not an string or fmt.Stringer: " + attr.Key)
+// tree yields err and every error it wraps, depth first in the order used by
+// errors.Is. It follows both Unwrap() error and Unwrap() []error, so the
+// errors collected by a Multi or the Attempts of Retry are included.
+func tree(err error) iter.Seq[error] {
+	return func(yield func(error) bool) {
+		walkTree(err, yield)
+	}
+}
+
+func walkTree(err error, yield func(error) bool) bool {
+	if err == nil {
+		return true
+	}
+	if !yield(err) {
+		return false
+	}
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		return walkTree(u.Unwrap(), yield)
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			if !walkTree(e, yield) {
+				return false
 			}
-			args = append(args, attr)
 		}
 	}
-	return args
+	return true
 }
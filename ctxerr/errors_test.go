@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/nveeser/srvsrv/ctxerr/internal/hooks"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -22,19 +24,19 @@ func init() {
 }
 
 func setupFrame() func() {
-	orig := callerFrame
-	var count int
-	callerFrame = func(p []uintptr, n int) *frame {
-		count++
-		frame := orig(p, n)
-		if strings.HasPrefix(frame.file, stackPathPrefix) {
-			frame.file = strings.Replace(frame.file, stackPathPrefix, "/foo/src/", 1)
-			frame.line = count
+	hook := func(frames []hooks.Frame) []hooks.Frame {
+		out := slices.Clone(frames)
+		for i := range out {
+			if strings.HasPrefix(out[i].File, stackPathPrefix) {
+				out[i].File = strings.Replace(out[i].File, stackPathPrefix, "/foo/src/", 1)
+				out[i].Line = i + 1
+			}
 		}
-		return frame
+		return out
 	}
+	prev := hooks.Frames.Swap(&hook)
 	return func() {
-		callerFrame = orig
+		hooks.Frames.Store(prev)
 	}
 }
 
@@ -46,14 +48,38 @@ func TestFormatError(t *testing.T) {
 		err := myFunc1()
 		got := fmt.Sprintf("%+v", err)
 		var want = `
-[op] 
-error happened
-	/foo/src/ctxerr/errors_test.go:7 
-	   srvsrv/ctxerr.myFunc1(...)
-	/foo/src/ctxerr/errors_test.go:9 
-	   srvsrv/ctxerr.T.myFunc2(...)
-	/foo/src/ctxerr/errors_test.go:10 
-	   srvsrv/ctxerr.myFunc3(...)`
+op
+  error happened
+	/foo/src/ctxerr/errors_test.go:3 
+	   github.com/nveeser/srvsrv/ctxerr.myFunc1(...)
+	/foo/src/ctxerr/errors_test.go:2 
+	   github.com/nveeser/srvsrv/ctxerr.T.myFunc2(...)
+	/foo/src/ctxerr/errors_test.go:1 
+	   github.com/nveeser/srvsrv/ctxerr.myFunc3(...)`
+
+		if diff := cmp.Diff(want, got, cmpopts.AcyclicTransformer("trim", strings.TrimSpace)); diff != "" {
+			t.Logf("Diff: -want/+got %s", diff)
+			t.Logf("got\n%s\n", got)
+			t.Logf("wanted\n%s\n", want)
+			t.Fail()
+		}
+	})
+	t.Run("StackFormat", func(t *testing.T) {
+		defer SetStackFormat(StackOptions{})
+		SetStackFormat(StackOptions{
+			TrimModules:  []string{"main"},
+			ElidePackage: true,
+			Depth:        2,
+		})
+		err := myFunc1()
+		got := fmt.Sprintf("%+v", err)
+		var want = `
+op
+  error happened
+	ctxerr/errors_test.go:2 
+	   T.myFunc2(...)
+	ctxerr/errors_test.go:1 
+	   myFunc3(...)`
 
 		if diff := cmp.Diff(want, got, cmpopts.AcyclicTransformer("trim", strings.TrimSpace)); diff != "" {
 			t.Logf("Diff: -want/+got %s", diff)
@@ -66,10 +92,10 @@ error happened
 		err := E(Op("one"), "error", E(Op("two"), "error building foo", E(Op("three"), "error building bar", errors.New("concrete"))))
 		got := fmt.Sprintf("%+v", err)
 		want := `
-[one] : error
-[two] : error building foo
-[three] : error building bar
-concrete
+one: error
+  two: error building foo
+    three: error building bar
+      concrete
 `
 		if diff := cmp.Diff(want, got, cmpopts.AcyclicTransformer("trim", strings.TrimSpace)); diff != "" {
 			t.Logf("Diff: -want/+got %s", diff)
@@ -0,0 +1,238 @@
+// Package errtest provides assertions on ctxerr errors for tests, so that
+// tests check the Op, Kind and attributes of an error rather than its text:
+//
+//	errtest.Check(t, err, errtest.HasOp("store.Get"), errtest.HasKind(ctxerr.NotFound))
+//
+// It also provides go-cmp options to compare *ctxerr.Error values, and a
+// deterministic stack mode for golden tests.
+package errtest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nveeser/srvsrv/ctxerr"
+	"github.com/nveeser/srvsrv/ctxerr/internal/hooks"
+	"log/slog"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Matcher checks an error, returning nil if it matches or an error that
+// describes the mismatch.
+type Matcher func(err error) error
+
+// Check reports a test error for each matcher that err does not match.
+func Check(t testing.TB, err error, ms ...Matcher) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("got nil error wanted non-nil")
+		return
+	}
+	for _, m := range ms {
+		if merr := m(err); merr != nil {
+			t.Errorf("%v: %s", err, merr)
+		}
+	}
+}
+
+// errs returns the *ctxerr.Error values in the chain of err, outermost first.
+// Errors that wrap several errors, such as a ctxerr.Multi, are walked depth
+// first as errors.Is does.
+func errs(err error) []*ctxerr.Error {
+	var out []*ctxerr.Error
+	switch u := err.(type) {
+	case nil:
+		return nil
+	case *ctxerr.Error:
+		out = append(out, u)
+		out = append(out, errs(u.Err)...)
+	case interface{ Unwrap() error }:
+		out = append(out, errs(u.Unwrap())...)
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			out = append(out, errs(e)...)
+		}
+	}
+	return out
+}
+
+// HasOp matches an error with op in its chain.
+func HasOp(op ctxerr.Op) Matcher {
+	return func(err error) error {
+		var ops []string
+		for _, e := range errs(err) {
+			if e.Op == op {
+				return nil
+			}
+			if e.Op != "" {
+				ops = append(ops, string(e.Op))
+			}
+		}
+		return fmt.Errorf("got ops %q wanted %q", ops, op)
+	}
+}
+
+// HasKind matches an error whose ctxerr.KindOf is k.
+func HasKind(k ctxerr.Kind) Matcher {
+	return func(err error) error {
+		if got := ctxerr.KindOf(err); got != k {
+			return fmt.Errorf("got kind %q wanted %q", got, k)
+		}
+		return nil
+	}
+}
+
+// HasAttr matches an error whose attribute key, as returned by
+// ctxerr.LookupAttr with ctxerr.Outermost, equals value.
+func HasAttr(key string, value any) Matcher {
+	return func(err error) error {
+		got, ok := ctxerr.LookupAttr(err, key, ctxerr.Outermost)
+		if !ok {
+			return fmt.Errorf("got no attribute %q wanted %v", key, value)
+		}
+		if want := slog.AnyValue(value); !got.Resolve().Equal(want.Resolve()) {
+			return fmt.Errorf("got attribute %s=%v wanted %v", key, got, want)
+		}
+		return nil
+	}
+}
+
+// ChainMatches matches an error whose chain has one level per pattern, each
+// matching the regular expression. A level is the "op: msg" of an
+// *ctxerr.Error, or the text of the first other error, which ends the chain.
+func ChainMatches(patterns ...string) Matcher {
+	return func(err error) error {
+		levels := chain(err)
+		if len(levels) != len(patterns) {
+			return fmt.Errorf("got chain %q wanted %d levels", levels, len(patterns))
+		}
+		for i, p := range patterns {
+			re, rerr := regexp.Compile(p)
+			if rerr != nil {
+				return fmt.Errorf("pattern %d: %w", i, rerr)
+			}
+			if !re.MatchString(levels[i]) {
+				return fmt.Errorf("got chain %q wanted level %d to match %q", levels, i, p)
+			}
+		}
+		return nil
+	}
+}
+
+// chain returns the text of each level of err.
+func chain(err error) []string {
+	var levels []string
+	for curr := err; curr != nil; {
+		e, ok := curr.(*ctxerr.Error)
+		if !ok {
+			return append(levels, curr.Error())
+		}
+		var parts []string
+		if e.Op != "" {
+			parts = append(parts, string(e.Op))
+		}
+		if e.Msg != "" {
+			parts = append(parts, e.Msg)
+		}
+		levels = append(levels, strings.Join(parts, ": "))
+		curr = e.Err
+	}
+	return levels
+}
+
+// StackContains matches an error whose stack, as returned by
+// (*ctxerr.Error).StackTrace, has a frame of the function fn. fn is compared
+// with the end of the fully qualified name, so "Queue.Push" matches
+// "github.com/nveeser/srvsrv/syncq.(*Queue[...]).Push" after the pointer
+// receiver and type parameters are removed.
+func StackContains(fn string) Matcher {
+	return func(err error) error {
+		var e *ctxerr.Error
+		if !errors.As(err, &e) {
+			return fmt.Errorf("got %T wanted *ctxerr.Error", err)
+		}
+		frames := e.StackTrace()
+		if len(frames) == 0 {
+			return fmt.Errorf("got no stack wanted frame %s", fn)
+		}
+		var names []string
+		for _, f := range frames {
+			name := funcName(f.Function)
+			if name == fn || strings.HasSuffix(name, "."+fn) || strings.HasSuffix(name, "/"+fn) {
+				return nil
+			}
+			names = append(names, name)
+		}
+		return fmt.Errorf("got stack %q wanted frame %s", names, fn)
+	}
+}
+
+// funcName simplifies a runtime function name, removing pointer receivers
+// and type parameters: "pkg.(*T[...]).M" becomes "pkg.T.M".
+func funcName(name string) string {
+	return strings.NewReplacer("(*", "", ")", "", "[...]", "").Replace(name)
+}
+
+// IgnoreStack returns options for cmp.Equal and cmp.Diff that compare
+// *ctxerr.Error values by their exported fields, ignoring the captured stack
+// and Origin. Causes that are not *ctxerr.Error are compared by their text,
+// and attributes with slog.Value.Equal.
+func IgnoreStack() cmp.Option {
+	return cmp.Options{
+		cmp.Transformer("ctxerr.Error", toView),
+		cmp.Comparer(func(a, b slog.Attr) bool { return a.Equal(b) }),
+	}
+}
+
+// errorView is the part of an *ctxerr.Error compared by IgnoreStack.
+type errorView struct {
+	Op        ctxerr.Op
+	Kind      ctxerr.Kind
+	Msg       string
+	Attrs     []slog.Attr
+	Prefix    []slog.Attr
+	Temporary bool
+	// Cause is the *ctxerr.Error cause, or the text of any other cause.
+	Cause any
+}
+
+func toView(e *ctxerr.Error) errorView {
+	if e == nil {
+		return errorView{}
+	}
+	v := errorView{
+		Op:        e.Op,
+		Kind:      e.Kind,
+		Msg:       e.Msg,
+		Attrs:     e.Attrs,
+		Prefix:    e.Prefix,
+		Temporary: e.Temporary,
+	}
+	switch cause := e.Err.(type) {
+	case nil:
+	case *ctxerr.Error:
+		v.Cause = cause
+	default:
+		v.Cause = cause.Error()
+	}
+	return v
+}
+
+// DeterministicStacks makes the stacks rendered by ctxerr the same on every
+// machine and after unrelated edits, for golden tests: files are reduced to
+// their base name and lines are omitted. It is undone when the test ends.
+// Tests using it must not run in parallel with tests that render stacks.
+func DeterministicStacks(t testing.TB) {
+	hook := func(frames []hooks.Frame) []hooks.Frame {
+		out := make([]hooks.Frame, len(frames))
+		for i, f := range frames {
+			out[i] = hooks.Frame{File: path.Base(f.File), Func: f.Func}
+		}
+		return out
+	}
+	prev := hooks.Frames.Swap(&hook)
+	t.Cleanup(func() { hooks.Frames.Store(prev) })
+}
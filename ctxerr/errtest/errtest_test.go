@@ -0,0 +1,95 @@
+package errtest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nveeser/srvsrv/ctxerr"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func lookup() error {
+	return ctxerr.E(ctxerr.Op("store.Get"), ctxerr.NotFound, "row missing", slog.String("table", "items"), io.EOF)
+}
+
+func TestMatchers(t *testing.T) {
+	err := ctxerr.E(ctxerr.Op("api.Item"), slog.Int("id", 3), lookup())
+
+	tests := []struct {
+		name  string
+		m     Matcher
+		match bool
+	}{
+		{"HasOpOuter", HasOp("api.Item"), true},
+		{"HasOpInner", HasOp("store.Get"), true},
+		{"HasOpMissing", HasOp("store.Put"), false},
+		{"HasKind", HasKind(ctxerr.NotFound), true},
+		{"HasKindWrong", HasKind(ctxerr.Invalid), false},
+		{"HasAttrInt", HasAttr("id", 3), true},
+		{"HasAttrInner", HasAttr("table", "items"), true},
+		{"HasAttrWrong", HasAttr("id", 4), false},
+		{"HasAttrMissing", HasAttr("user", "x"), false},
+		{"ChainMatches", ChainMatches("^api.Item$", "^store.Get: row", "EOF"), true},
+		{"ChainMatchesShort", ChainMatches("api.Item", "store.Get"), false},
+		{"ChainMatchesWrong", ChainMatches("api.Item", "store.Put", "EOF"), false},
+		{"StackContains", StackContains("lookup"), true},
+		{"StackContainsMissing", StackContains("notCalled"), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			merr := tc.m(err)
+			if got := merr == nil; got != tc.match {
+				t.Errorf("match got %t wanted %t: %v", got, tc.match, merr)
+			}
+		})
+	}
+}
+
+func TestMatchersMulti(t *testing.T) {
+	m := ctxerr.NewMulti(ctxerr.Op("pool"))
+	m.Add(io.EOF)
+	m.Add(lookup())
+	Check(t, m, HasOp("store.Get"), HasKind(ctxerr.NotFound), HasAttr("table", "items"))
+}
+
+func TestStackContainsPlain(t *testing.T) {
+	if err := StackContains("lookup")(io.EOF); err == nil {
+		t.Errorf("StackContains(io.EOF) got match wanted error")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	Check(t, lookup(), HasOp("store.Get"), HasKind(ctxerr.NotFound), ChainMatches("store.Get", "EOF"))
+}
+
+func TestIgnoreStack(t *testing.T) {
+	a := lookup()
+	b := ctxerr.E(ctxerr.Op("store.Get"), ctxerr.NotFound, "row missing", slog.String("table", "items"), errors.New("EOF"))
+	if diff := cmp.Diff(a, b, IgnoreStack()); diff != "" {
+		t.Errorf("Diff got diff -a/+b: %s", diff)
+	}
+
+	wrapped := ctxerr.E(ctxerr.Op("api.Item"), a)
+	c := ctxerr.E(ctxerr.Op("api.Item"), ctxerr.E(ctxerr.Op("store.Get"), ctxerr.NotFound, "row missing", slog.String("table", "other"), io.EOF))
+	diff := cmp.Diff(wrapped, c, IgnoreStack())
+	if !strings.Contains(diff, "other") {
+		t.Errorf("Diff got %q wanted difference in attributes", diff)
+	}
+}
+
+func TestDeterministicStacks(t *testing.T) {
+	DeterministicStacks(t)
+	err := lookup()
+	got := fmt.Sprintf("%+v", err)
+	if !strings.Contains(got, "\terrtest_test.go ") || strings.Contains(got, ".go:") {
+		t.Errorf("Format got %s wanted base file names without lines", got)
+	}
+	for _, f := range err.(*ctxerr.Error).StackTrace() {
+		if strings.Contains(f.File, "/") || f.Line != 0 {
+			t.Errorf("StackTrace got frame %s wanted base file name and no line", f)
+		}
+	}
+}
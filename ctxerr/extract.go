@@ -0,0 +1,110 @@
+package ctxerr
+
+import (
+	"context"
+	"github.com/cyrusaf/ctxlog"
+	"log/slog"
+	"sync/atomic"
+)
+
+// AttrExtractor returns the attributes carried by a context, which are added
+// to errors created with the context.
+type AttrExtractor interface {
+	Attrs(ctx context.Context) []slog.Attr
+}
+
+// AttrExtractorFunc is a function that implements AttrExtractor.
+type AttrExtractorFunc func(ctx context.Context) []slog.Attr
+
+func (f AttrExtractorFunc) Attrs(ctx context.Context) []slog.Attr { return f(ctx) }
+
+// Built-in extractors.
+var (
+	// Ctxlog extracts the attributes added with ctxlog.WithAttrs.
+	Ctxlog AttrExtractor = AttrExtractorFunc(ctxlog.GetAttrs)
+	// Native extracts the attributes added with ContextWithAttrs.
+	Native AttrExtractor = AttrExtractorFunc(AttrsFromContext)
+	// Trace extracts the trace and span IDs added with ContextWithTrace as
+	// "trace_id" and "span_id".
+	Trace AttrExtractor = AttrExtractorFunc(traceAttrs)
+)
+
+var extractors atomic.Pointer[[]AttrExtractor]
+
+// SetAttrExtractors sets the extractors used to read attributes from a
+// context passed to E() or New. The default is Ctxlog, Native and Trace.
+// Programs not using ctxlog can drop Ctxlog, and others can add their own.
+func SetAttrExtractors(es ...AttrExtractor) {
+	extractors.Store(&es)
+}
+
+var defaultExtractors = []AttrExtractor{Ctxlog, Native, Trace}
+
+// ContextAttrs returns the attributes of ctx from every extractor set with
+// SetAttrExtractors, which are those added to errors created with ctx.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	es := defaultExtractors
+	if p := extractors.Load(); p != nil {
+		es = *p
+	}
+	var attrs []slog.Attr
+	for _, e := range es {
+		attrs = append(attrs, e.Attrs(ctx)...)
+	}
+	return attrs
+}
+
+type attrsKey struct{}
+
+// ContextWithAttrs returns a copy of ctx carrying the attributes in addition
+// to those already added, for programs that do not use ctxlog. The
+// attributes are added to errors created with the context by the Native
+// extractor.
+func ContextWithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev := AttrsFromContext(ctx)
+	all := make([]slog.Attr, 0, len(prev)+len(attrs))
+	all = append(append(all, prev...), attrs...)
+	return context.WithValue(ctx, attrsKey{}, all)
+}
+
+// AttrsFromContext returns the attributes added with ContextWithAttrs.
+func AttrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// TraceContext identifies the trace and span of a request, in the hex
+// encoding used by OpenTelemetry.
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+}
+
+type traceKey struct{}
+
+// ContextWithTrace returns a copy of ctx carrying the trace and span IDs, such
+// as those received in a traceparent header.
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, tc)
+}
+
+// TraceFromContext returns the TraceContext added with ContextWithTrace.
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey{}).(TraceContext)
+	return tc, ok
+}
+
+func traceAttrs(ctx context.Context) []slog.Attr {
+	tc, ok := TraceFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	var attrs []slog.Attr
+	if tc.TraceID != "" {
+		attrs = append(attrs, slog.String("trace_id", tc.TraceID))
+	}
+	if tc.SpanID != "" {
+		attrs = append(attrs, slog.String("span_id", tc.SpanID))
+	}
+	return attrs
+}
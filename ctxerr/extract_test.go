@@ -0,0 +1,73 @@
+package ctxerr
+
+import (
+	"context"
+	"github.com/cyrusaf/ctxlog"
+	"github.com/google/go-cmp/cmp"
+	"log/slog"
+	"testing"
+)
+
+func TestAttrExtractors(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(), slog.String("module", "db"))
+	ctx = ContextWithAttrs(ctx, slog.String("user", "bob"))
+	ctx = ContextWithAttrs(ctx, slog.Int("call", 3))
+	ctx = ContextWithTrace(ctx, TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"})
+
+	cases := []struct {
+		name string
+		es   []AttrExtractor
+		want []slog.Attr
+	}{
+		{
+			name: "Default",
+			want: []slog.Attr{
+				slog.String("module", "db"),
+				slog.String("user", "bob"),
+				slog.Int("call", 3),
+				slog.String("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+				slog.String("span_id", "00f067aa0ba902b7"),
+			},
+		},
+		{
+			name: "WithoutCtxlog",
+			es:   []AttrExtractor{Native},
+			want: []slog.Attr{slog.String("user", "bob"), slog.Int("call", 3)},
+		},
+		{
+			name: "Custom",
+			es: []AttrExtractor{AttrExtractorFunc(func(context.Context) []slog.Attr {
+				return []slog.Attr{slog.String("custom", "x")}
+			})},
+			want: []slog.Attr{slog.String("custom", "x")},
+		},
+	}
+	defer SetAttrExtractors(defaultExtractors...)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.es != nil {
+				SetAttrExtractors(tc.es...)
+			} else {
+				SetAttrExtractors(defaultExtractors...)
+			}
+			e := E(Op("op"), ctx, "msg").(*Error)
+			if diff := cmp.Diff(tc.want, e.Attrs, cmpAttrs); diff != "" {
+				t.Errorf("E() attrs got diff -want/+got: %s", diff)
+			}
+			e = New(WithOp("op"), WithContext(ctx)).(*Error)
+			if diff := cmp.Diff(tc.want, e.Attrs, cmpAttrs); diff != "" {
+				t.Errorf("New() attrs got diff -want/+got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestContextWithAttrsPrefix(t *testing.T) {
+	ctx := ContextWithAttrs(context.Background(), slog.String("module", "billing"))
+	if got, want := ContextError(ctx, errSentinel).Error(), "[billing]: sentinel happened"; got != want {
+		t.Errorf("ContextError() got %q wanted %q", got, want)
+	}
+	if _, ok := TraceFromContext(ctx); ok {
+		t.Errorf("TraceFromContext() got true wanted false")
+	}
+}
@@ -0,0 +1,200 @@
+// Package httperr reports ctxerr errors from HTTP handlers.
+//
+// Handler adds the attributes of each request, including a correlation ID, to
+// the request context, with ctxlog by default, so that errors created with
+// the context carry them. WriteError writes an error as an RFC 7807
+// problem+json response, with the status taken from the Kind of the error:
+//
+//	func get(w http.ResponseWriter, r *http.Request) error {
+//		if err := load(r.Context()); err != nil {
+//			return ctxerr.E(ctxerr.Op("get"), r.Context(), httperr.Public("no such item"), err)
+//		}
+//		...
+//	}
+//
+//	http.Handle("/item", httperr.Handler(httperr.HandlerFunc(get), httperr.Options{}))
+//
+// Only messages marked with Public are sent to the client. The message of the
+// error itself may hold internal details and is only sent if
+// Options.Internal is set.
+package httperr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/cyrusaf/ctxlog"
+	"github.com/nveeser/srvsrv/ctxerr"
+	"log/slog"
+	"net/http"
+)
+
+// PublicKey is the attribute key of the message sent to clients, see Public.
+const PublicKey = "public"
+
+// Public returns an attribute holding a message that is safe to send to the
+// client, for use as an argument to ctxerr.E().
+func Public(msg string) slog.Attr {
+	return slog.String(PublicKey, msg)
+}
+
+// Problem is the body of an RFC 7807 problem+json response.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// CorrelationID identifies the request in the server logs.
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// Internal is the text of the error, only set if Options.Internal is set.
+	Internal string `json:"internal,omitempty"`
+}
+
+// Options controls Handler and WriteError. The zero value is ready to use.
+type Options struct {
+	// IDHeader is the request and response header holding the correlation
+	// ID, "X-Request-Id" if empty.
+	IDHeader string
+	// IDKey is the attribute key of the correlation ID, "request_id" if
+	// empty.
+	IDKey string
+	// NewID returns the correlation ID for requests without one, a random
+	// hex string if nil.
+	NewID func() string
+	// Internal sends the text of the error in responses. Use only in
+	// development.
+	Internal bool
+	// Logger logs errors with a status of 500 or more, if not nil.
+	Logger *slog.Logger
+	// WithAttrs adds the attributes of each request to its context,
+	// ctxlog.WithAttrs if nil. Programs not using ctxlog can use
+	// ctxerr.ContextWithAttrs. The attributes must be read by one of the
+	// extractors set with ctxerr.SetAttrExtractors.
+	WithAttrs func(ctx context.Context, attrs ...slog.Attr) context.Context
+}
+
+func (o Options) idHeader() string {
+	if o.IDHeader != "" {
+		return o.IDHeader
+	}
+	return "X-Request-Id"
+}
+
+func (o Options) idKey() string {
+	if o.IDKey != "" {
+		return o.IDKey
+	}
+	return "request_id"
+}
+
+func (o Options) withAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if o.WithAttrs != nil {
+		return o.WithAttrs(ctx, attrs...)
+	}
+	return ctxlog.WithAttrs(ctx, attrs...)
+}
+
+func (o Options) newID() string {
+	if o.NewID != nil {
+		return o.NewID()
+	}
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Handler returns middleware that adds the method, path and correlation ID of
+// each request to its context with WithAttrs. The correlation ID is taken
+// from the IDHeader of the request, or created with NewID, and is echoed in
+// the response header.
+func Handler(next http.Handler, opts Options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(opts.idHeader())
+		if id == "" {
+			id = opts.newID()
+		}
+		w.Header().Set(opts.idHeader(), id)
+		ctx := opts.withAttrs(r.Context(),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String(opts.idKey(), id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// HandlerFunc is an http.Handler that returns an error, which is written with
+// WriteError.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f(w, r); err != nil {
+		WriteError(w, r, err)
+	}
+}
+
+// WriteError writes err as a problem+json response with the default Options.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	Options{}.WriteError(w, r, err)
+}
+
+// WriteError writes err as a problem+json response. Nothing is written if err
+// is nil.
+func (o Options) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	p := o.Problem(r, err)
+	if o.Logger != nil && p.Status >= http.StatusInternalServerError {
+		o.Logger.ErrorContext(r.Context(), "request failed", slog.Any("error", err))
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Problem returns the problem+json body for err. The status is the HTTP status
+// of its Kind, the detail is its Public message, and the correlation ID is its
+// IDKey attribute, or that of the request context if the error has none.
+func (o Options) Problem(r *http.Request, err error) Problem {
+	status := ctxerr.KindOf(err).HTTPStatus()
+	p := Problem{
+		Title:    statusText(status),
+		Status:   status,
+		Instance: r.URL.Path,
+	}
+	if v, ok := ctxerr.LookupAttr(err, PublicKey, ctxerr.Outermost); ok {
+		p.Detail = v.String()
+	}
+	if v, ok := ctxerr.LookupAttr(err, o.idKey(), ctxerr.Outermost); ok {
+		p.CorrelationID = v.String()
+	} else {
+		p.CorrelationID = lookupContext(r.Context(), o.idKey())
+	}
+	if o.Internal {
+		p.Internal = err.Error()
+	}
+	return p
+}
+
+// statusText returns the text of the HTTP status, including 499 which is not
+// registered with net/http.
+func statusText(status int) string {
+	if status == 499 {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
+
+// lookupContext returns the last value of the context attribute with the key,
+// as read by the ctxerr extractors.
+func lookupContext(ctx context.Context, key string) string {
+	var v string
+	for _, a := range ctxerr.ContextAttrs(ctx) {
+		if a.Key == key {
+			v = a.Value.String()
+		}
+	}
+	return v
+}
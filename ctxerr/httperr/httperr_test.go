@@ -0,0 +1,155 @@
+package httperr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/cyrusaf/ctxlog"
+	"github.com/google/go-cmp/cmp"
+	"github.com/nveeser/srvsrv/ctxerr"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, h http.Handler, header string) (*http.Response, Problem) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/item/3", nil)
+	if header != "" {
+		req.Header.Set("X-Request-Id", header)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	resp := rec.Result()
+	var p Problem
+	if resp.Header.Get("Content-Type") == "application/problem+json" {
+		if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+			t.Fatalf("Decode() got error: %s", err)
+		}
+	}
+	return resp, p
+}
+
+func TestWriteError(t *testing.T) {
+	h := Handler(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return ctxerr.E(ctxerr.Op("get"), r.Context(), ctxerr.NotFound, Public("no such item"), "row 3 missing in shard 7")
+	}), Options{})
+
+	resp, got := serve(t, h, "req-1")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("StatusCode got %d wanted %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if id := resp.Header.Get("X-Request-Id"); id != "req-1" {
+		t.Errorf("X-Request-Id got %q wanted %q", id, "req-1")
+	}
+	want := Problem{
+		Title:         "Not Found",
+		Status:        http.StatusNotFound,
+		Detail:        "no such item",
+		Instance:      "/item/3",
+		CorrelationID: "req-1",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Problem got diff -want/+got: %s", diff)
+	}
+}
+
+func TestWriteErrorInternal(t *testing.T) {
+	var logs bytes.Buffer
+	opts := Options{
+		NewID:    func() string { return "generated" },
+		Internal: true,
+		Logger:   slog.New(ctxlog.NewHandler(slog.NewJSONHandler(&logs, nil))),
+	}
+	// The error does not carry the context, so the correlation ID comes
+	// from the request.
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts.WriteError(w, r, ctxerr.E(ctxerr.Op("get"), io.ErrUnexpectedEOF))
+	}), opts)
+
+	resp, got := serve(t, h, "")
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("StatusCode got %d wanted %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+	want := Problem{
+		Title:         "Internal Server Error",
+		Status:        http.StatusInternalServerError,
+		Instance:      "/item/3",
+		CorrelationID: "generated",
+		Internal:      "get: unexpected EOF",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Problem got diff -want/+got: %s", diff)
+	}
+	if !strings.Contains(logs.String(), `"request_id":"generated"`) {
+		t.Errorf("Logger got %s wanted request_id", logs.String())
+	}
+}
+
+func TestWriteErrorPlain(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("secret detail")
+	})
+	resp, got := serve(t, h, "")
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("StatusCode got %d wanted %d", resp.StatusCode, http.StatusInternalServerError)
+	}
+	if got.Detail != "" || got.Internal != "" {
+		t.Errorf("Problem got %+v wanted no detail", got)
+	}
+}
+
+func TestWriteErrorCanceled(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return ctxerr.E(ctxerr.Op("get"), ctxerr.Canceled, "client went away")
+	})
+	resp, got := serve(t, h, "")
+	if resp.StatusCode != 499 || got.Title != "Client Closed Request" {
+		t.Errorf("Problem got %d %q wanted 499 %q", resp.StatusCode, got.Title, "Client Closed Request")
+	}
+}
+
+func TestHandlerWithAttrs(t *testing.T) {
+	h := Handler(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if attrs := ctxlog.GetAttrs(r.Context()); len(attrs) != 0 {
+			t.Errorf("ctxlog.GetAttrs() got %v wanted none", attrs)
+		}
+		if r.URL.Query().Has("ctx") {
+			return ctxerr.E(ctxerr.Op("get"), r.Context(), ctxerr.NotFound, "missing")
+		}
+		return ctxerr.E(ctxerr.Op("get"), ctxerr.NotFound, "missing")
+	}), Options{WithAttrs: ctxerr.ContextWithAttrs})
+
+	for _, target := range []string{"/item/3?ctx", "/item/3"} {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("X-Request-Id", "req-2")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var got Problem
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("Decode() got error: %s", err)
+		}
+		if got.CorrelationID != "req-2" {
+			t.Errorf("%s: CorrelationID got %q wanted %q", target, got.CorrelationID, "req-2")
+		}
+	}
+}
+
+func TestHandlerNoError(t *testing.T) {
+	h := Handler(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		io.WriteString(w, "ok")
+		return nil
+	}), Options{IDHeader: "X-Trace"})
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("ServeHTTP got %d %q wanted 200 %q", rec.Code, rec.Body.String(), "ok")
+	}
+	if rec.Header().Get("X-Trace") == "" {
+		t.Errorf("X-Trace header not set")
+	}
+}
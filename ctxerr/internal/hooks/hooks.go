@@ -0,0 +1,16 @@
+// Package hooks holds the test hooks shared by ctxerr and ctxerr/errtest.
+package hooks
+
+import "sync/atomic"
+
+// Frame is a symbolized stack frame.
+type Frame struct {
+	File string
+	Line int
+	Func string
+}
+
+// Frames, when set, rewrites each stack, innermost frame first, as it is
+// rendered by Format, logged, serialized or returned by StackTrace. It is not
+// applied when symbolizing frames for other uses, such as finding GOROOT.
+var Frames atomic.Pointer[func([]Frame) []Frame]
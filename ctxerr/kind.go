@@ -0,0 +1,145 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// Kind classifies an error. A Kind is passed to E() to set the Kind of the
+// *Error, and is itself an error so errors.Is(err, NotFound) reports whether
+// any *Error in the chain of err has that Kind.
+type Kind uint8
+
+const (
+	Other           Kind = iota // Unclassified error.
+	Invalid                     // Invalid argument or request.
+	NotFound                    // Item does not exist.
+	Exist                       // Item already exists.
+	Permission                  // Permission denied.
+	Unauthenticated             // Caller identity could not be verified.
+	Unavailable                 // Service is unavailable, typically temporarily.
+	Timeout                     // Deadline expired.
+	Canceled                    // Operation was canceled.
+	Unimplemented               // Operation is not implemented.
+	Internal                    // Internal error or inconsistency.
+)
+
+var kindNames = [...]string{
+	Other:           "other error",
+	Invalid:         "invalid argument",
+	NotFound:        "not found",
+	Exist:           "already exists",
+	Permission:      "permission denied",
+	Unauthenticated: "unauthenticated",
+	Unavailable:     "unavailable",
+	Timeout:         "timeout",
+	Canceled:        "canceled",
+	Unimplemented:   "unimplemented",
+	Internal:        "internal error",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown error kind"
+}
+
+// Error implements error so a Kind can be used as the target of errors.Is.
+func (k Kind) Error() string { return k.String() }
+
+// Is reports whether target is the Kind of this Error. It is used by
+// errors.Is, so errors.Is(err, NotFound) is true if any *Error in the
+// chain has Kind NotFound.
+func (e *Error) Is(target error) bool {
+	k, ok := target.(Kind)
+	return ok && k != Other && e.Kind == k
+}
+
+// KindOf returns the Kind of the outermost *Error or Sentinel in the chain of
+// err that is not Other. Errors that wrap several errors, such as a Multi, are
+// searched depth first in the order used by errors.Is. Context errors without a Kind are reported as Canceled or
+// Timeout. If no Kind is found Other is returned.
+func KindOf(err error) Kind {
+	for curr := range tree(err) {
+		switch e := curr.(type) {
+		case *Error:
+			if e.Kind != Other {
+				return e.Kind
+			}
+		case *Sentinel:
+			if e.kind != Other {
+				return e.kind
+			}
+		case Kind:
+			return e
+		}
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout
+	}
+	return Other
+}
+
+var httpStatus = [...]int{
+	Other:           http.StatusInternalServerError,
+	Invalid:         http.StatusBadRequest,
+	NotFound:        http.StatusNotFound,
+	Exist:           http.StatusConflict,
+	Permission:      http.StatusForbidden,
+	Unauthenticated: http.StatusUnauthorized,
+	Unavailable:     http.StatusServiceUnavailable,
+	Timeout:         http.StatusGatewayTimeout,
+	Canceled:        499, // Client Closed Request
+	Unimplemented:   http.StatusNotImplemented,
+	Internal:        http.StatusInternalServerError,
+}
+
+// HTTPStatus returns the HTTP status code used to report an error of this Kind.
+func (k Kind) HTTPStatus() int {
+	if int(k) < len(httpStatus) {
+		return httpStatus[k]
+	}
+	return http.StatusInternalServerError
+}
+
+// Level returns the level an error of this Kind is logged at: slog.LevelError
+// for failures of the server, those with a 5xx HTTPStatus, and slog.LevelWarn
+// for errors caused by the request.
+func (k Kind) Level() slog.Level {
+	if k.HTTPStatus() >= 500 {
+		return slog.LevelError
+	}
+	return slog.LevelWarn
+}
+
+// Code is a gRPC status code. The values match google.golang.org/grpc/codes
+// so a Code can be converted directly with codes.Code(c).
+type Code uint32
+
+var grpcCodes = [...]Code{
+	Other:           2,  // Unknown
+	Invalid:         3,  // InvalidArgument
+	NotFound:        5,  // NotFound
+	Exist:           6,  // AlreadyExists
+	Permission:      7,  // PermissionDenied
+	Unauthenticated: 16, // Unauthenticated
+	Unavailable:     14, // Unavailable
+	Timeout:         4,  // DeadlineExceeded
+	Canceled:        1,  // Canceled
+	Unimplemented:   12, // Unimplemented
+	Internal:        13, // Internal
+}
+
+// GRPCCode returns the gRPC status code used to report an error of this Kind.
+func (k Kind) GRPCCode() Code {
+	if int(k) < len(grpcCodes) {
+		return grpcCodes[k]
+	}
+	return 2 // Unknown
+}
@@ -0,0 +1,63 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func TestKind(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{"Nil", nil, Other},
+		{"Plain", errors.New("plain"), Other},
+		{"Direct", E(Op("op"), NotFound), NotFound},
+		{"Wrapped", E(Op("outer"), E(Op("inner"), Permission, errors.New("denied"))), Permission},
+		{"OutermostWins", E(Invalid, E(NotFound, "missing")), Invalid},
+		{"FmtWrapped", fmt.Errorf("wrapped: %w", E(Unavailable, "down")), Unavailable},
+		{"Context", E(Op("op"), context.DeadlineExceeded), Timeout},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := KindOf(tc.err); got != tc.want {
+				t.Errorf("KindOf() got %v wanted %v", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("ErrorsIs", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", E(Op("op"), NotFound, "missing"))
+		if !errors.Is(err, NotFound) {
+			t.Errorf("errors.Is(err, NotFound) got false wanted true")
+		}
+		if errors.Is(err, Permission) {
+			t.Errorf("errors.Is(err, Permission) got true wanted false")
+		}
+		if errors.Is(E(Op("op"), "msg"), Other) {
+			t.Errorf("errors.Is(err, Other) got true wanted false")
+		}
+	})
+	t.Run("Codes", func(t *testing.T) {
+		if got := NotFound.HTTPStatus(); got != http.StatusNotFound {
+			t.Errorf("HTTPStatus() got %d wanted %d", got, http.StatusNotFound)
+		}
+		if got := Kind(200).HTTPStatus(); got != http.StatusInternalServerError {
+			t.Errorf("HTTPStatus() got %d wanted %d", got, http.StatusInternalServerError)
+		}
+		if got := Unavailable.GRPCCode(); got != 14 {
+			t.Errorf("GRPCCode() got %d wanted %d", got, 14)
+		}
+		if got := Internal.Level(); got != slog.LevelError {
+			t.Errorf("Level() got %v wanted %v", got, slog.LevelError)
+		}
+		if got := Invalid.Level(); got != slog.LevelWarn {
+			t.Errorf("Level() got %v wanted %v", got, slog.LevelWarn)
+		}
+	})
+}
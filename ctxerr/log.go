@@ -0,0 +1,203 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// LogValue implements slog.LogValuer. The Error is logged as a group
+// with the op, msg and attrs of the Error, and the cause chain as nested
+// "cause" groups. The stack is not included, see LogOptions.
+func (e *Error) LogValue() slog.Value {
+	return LogOptions{}.Value(e)
+}
+
+// LogOptions controls how an error is converted to a slog.Value.
+type LogOptions struct {
+	// Stack adds the captured stack as a list of "file:line function" strings.
+	Stack bool
+}
+
+// Value returns the error as a slog.Value. Errors that are not *Error or
+// *Multi are logged as their Error() string.
+func (o LogOptions) Value(err error) slog.Value {
+	if m, ok := err.(*Multi); ok {
+		return o.multiValue(m)
+	}
+	e, ok := err.(*Error)
+	if !ok {
+		return slog.StringValue(err.Error())
+	}
+	var attrs []slog.Attr
+	if len(e.Prefix) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "prefix", Value: slog.GroupValue(renderAttrs(e.Prefix)...)})
+	}
+	if e.Op != "" {
+		attrs = append(attrs, slog.String("op", string(e.Op)))
+	}
+	if e.Kind != Other {
+		attrs = append(attrs, slog.String("kind", e.Kind.String()))
+	}
+	if e.Temporary {
+		attrs = append(attrs, slog.Bool("temporary", true))
+	}
+	if e.Msg != "" {
+		attrs = append(attrs, slog.String("msg", e.Msg))
+	}
+	if len(e.Attrs) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "attrs", Value: slog.GroupValue(renderAttrs(e.Attrs)...)})
+	}
+	if origin := e.Origin.attrs(); len(origin) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "origin", Value: slog.GroupValue(origin...)})
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.Attr{Key: "cause", Value: LogOptions{}.Value(e.Err)})
+	}
+	if o.Stack {
+		if stk := stackStrings(e); len(stk) > 0 {
+			attrs = append(attrs, slog.Any("stack", stk))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// stackStrings returns the stack of the innermost *Error in the chain.
+func stackStrings(err error) []string {
+	var out []string
+	var stacked *Error
+	for curr := err; curr != nil; curr = errors.Unwrap(curr) {
+		if ee, ok := curr.(*Error); ok && ee.hasStack() {
+			stacked = ee
+		}
+	}
+	if stacked == nil {
+		return nil
+	}
+	stacked.walkStack(0, func(file string, line int, fname string) {
+		var b strings.Builder
+		writeCallsite(&b, file, line)
+		b.WriteString(" ")
+		b.WriteString(fname)
+		out = append(out, b.String())
+	})
+	return out
+}
+
+// HandlerOptions are options for a Handler.
+type HandlerOptions struct {
+	// Stack adds the stack of the error as a field named "<key>.stack".
+	Stack bool
+}
+
+// Handler is a slog.Handler middleware that promotes the attributes of
+// *Error values to first-class log fields. For every attribute whose value
+// is an error containing an *Error, the attribute is logged as the Error()
+// string and the Attrs found in the chain are added to the record. A promoted
+// attribute never replaces one with the same key logged directly, or promoted
+// from an earlier error, so each key appears once.
+type Handler struct {
+	next slog.Handler
+	opts HandlerOptions
+	// keys holds the keys added by WithAttrs since the last WithGroup.
+	keys []string
+}
+
+// NewHandler returns a Handler that passes records to next.
+func NewHandler(next slog.Handler, opts *HandlerOptions) *Handler {
+	h := &Handler{next: next}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *Handler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	attrs, expanded := h.expandAll(attrs)
+	if !expanded {
+		return h.next.Handle(ctx, r)
+	}
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(attrs...)
+	return h.next.Handle(ctx, nr)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out, _ := h.expandAll(attrs)
+	keys := slices.Clone(h.keys)
+	for _, a := range out {
+		keys = append(keys, a.Key)
+	}
+	return &Handler{next: h.next.WithAttrs(out), opts: h.opts, keys: keys}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), opts: h.opts}
+}
+
+// expandAll replaces the attributes holding an *Error and adds the attributes
+// promoted from them whose keys are not already present. It reports whether
+// any attribute held an *Error.
+func (h *Handler) expandAll(attrs []slog.Attr) ([]slog.Attr, bool) {
+	seen := make(map[string]bool)
+	for _, k := range h.keys {
+		seen[k] = true
+	}
+	var out, promoted []slog.Attr
+	var expanded bool
+	for _, a := range attrs {
+		seen[a.Key] = true
+		if x, more, ok := h.expand(a); ok {
+			out = append(out, x)
+			promoted = append(promoted, more...)
+			expanded = true
+		} else {
+			out = append(out, a)
+		}
+	}
+	for _, a := range promoted {
+		if !seen[a.Key] {
+			seen[a.Key] = true
+			out = append(out, a)
+		}
+	}
+	return out, expanded
+}
+
+// expand returns the attribute to log in place of a, and the attributes to
+// promote, if a holds an *Error.
+func (h *Handler) expand(a slog.Attr) (slog.Attr, []slog.Attr, bool) {
+	if k := a.Value.Kind(); k != slog.KindAny && k != slog.KindLogValuer {
+		return a, nil, false
+	}
+	err, ok := a.Value.Any().(error)
+	if !ok {
+		return a, nil, false
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return a, nil, false
+	}
+	promoted := ChainAttrs(err, Outermost)
+	if h.opts.Stack {
+		if stk := stackStrings(err); len(stk) > 0 {
+			promoted = append(promoted, slog.Any(a.Key+".stack", stk))
+		}
+	}
+	return slog.String(a.Key, err.Error()), promoted, true
+}
+
+var _ slog.LogValuer = (*Error)(nil)
+var _ slog.LogValuer = (*Multi)(nil)
+var _ slog.Handler = (*Handler)(nil)
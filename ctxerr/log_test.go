@@ -0,0 +1,107 @@
+package ctxerr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/cyrusaf/ctxlog"
+	"github.com/google/go-cmp/cmp"
+	"log/slog"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(), slog.String("call", "lookup"))
+	err := E(Op("one"), "error", E(Op("two"), ctx, "error building foo", errors.New("concrete")))
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: dropBuiltins,
+	}))
+	logger.Info("failed", "error", err)
+
+	got := map[string]any{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() got error: %s", err)
+	}
+	want := map[string]any{
+		"error": map[string]any{
+			"op":  "one",
+			"msg": "error",
+			"cause": map[string]any{
+				"op":    "two",
+				"msg":   "error building foo",
+				"attrs": map[string]any{"call": "lookup"},
+				"cause": "concrete",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LogValue() got diff -want/+got: %s", diff)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(), slog.String("call", "lookup"), slog.String("module", "db"))
+	inner := E(Op("two"), ctx, "error building foo", errors.New("concrete"))
+	outer := E(Op("one"), ctxlog.WithAttrs(context.Background(), slog.String("call", "outer")), inner)
+
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: dropBuiltins,
+	}), nil))
+	logger.Info("failed", "error", outer, "other", 3)
+
+	got := map[string]any{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() got error: %s", err)
+	}
+	want := map[string]any{
+		"error":  outer.Error(),
+		"call":   "outer",
+		"module": "db",
+		"other":  float64(3),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Handle() got diff -want/+got: %s", diff)
+	}
+}
+
+func TestHandlerDuplicateKeys(t *testing.T) {
+	first := E(Op("first"), slog.String("call", "first"), slog.String("module", "db"))
+	second := E(Op("second"), slog.String("module", "cache"), slog.String("table", "items"))
+
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: dropBuiltins,
+	}), nil)).With("table", "logger")
+	logger.Info("failed", "error", first, "call", "record", "cause", second)
+
+	for _, key := range []string{"call", "module", "table"} {
+		if n := bytes.Count(buf.Bytes(), []byte(`"`+key+`":`)); n != 1 {
+			t.Errorf("Handle() got key %q %d times wanted once: %s", key, n, buf.Bytes())
+		}
+	}
+	got := map[string]any{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() got error: %s", err)
+	}
+	want := map[string]any{
+		"error":  first.Error(),
+		"cause":  second.Error(),
+		"call":   "record",
+		"module": "db",
+		"table":  "logger",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Handle() got diff -want/+got: %s", diff)
+	}
+}
+
+func dropBuiltins(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+		return slog.Attr{}
+	}
+	return a
+}
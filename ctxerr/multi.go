@@ -0,0 +1,210 @@
+package ctxerr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Multi collects errors from many sources, such as the workers consuming a
+// syncq.Queue, under a single Op and set of attributes. It is safe for
+// concurrent use.
+//
+// Multi is compatible with errors.Join: errors.Is and errors.As inspect each
+// collected error through Unwrap() []error.
+type Multi struct {
+	Op    Op
+	Attrs []slog.Attr
+
+	mu   sync.Mutex
+	errs []multiEntry
+}
+
+type multiEntry struct {
+	err   error
+	count int
+}
+
+// NewMulti returns an empty Multi. The arguments are interpreted as they are
+// by E(), though only the Op and attributes are kept.
+func NewMulti(args ...any) *Multi {
+	e := newError(args...)
+	return &Multi{Op: e.Op, Attrs: e.Attrs}
+}
+
+// Add adds the error to the collection. Nil errors are ignored. If an
+// identical error, one with the same message and the same root cause, was
+// already added it is counted rather than added again.
+func (m *Multi) Add(err error) {
+	if err == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.errs {
+		if identical(m.errs[i].err, err) {
+			m.errs[i].count++
+			return
+		}
+	}
+	m.errs = append(m.errs, multiEntry{err: err, count: 1})
+}
+
+// Len returns the number of distinct errors collected.
+func (m *Multi) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.errs)
+}
+
+// Err returns m if any errors were collected and nil otherwise.
+func (m *Multi) Err() error {
+	if m.Len() == 0 {
+		return nil
+	}
+	return m
+}
+
+// Unwrap returns the distinct errors collected.
+func (m *Multi) Unwrap() []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]error, len(m.errs))
+	for i, e := range m.errs {
+		out[i] = e.err
+	}
+	return out
+}
+
+func (m *Multi) entries() []multiEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]multiEntry(nil), m.errs...)
+}
+
+func (m *Multi) Error() string {
+	var b strings.Builder
+	m.writeSummary(&b)
+	for i, e := range m.entries() {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(e.err.Error())
+	}
+	return b.String()
+}
+
+// Format renders the %v verb as an indented tree, the attributes of the Multi
+// on the first line and each collected *Error rendered by the %+v Renderer of
+// Rendering.
+func (m *Multi) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'w', 's':
+		io.WriteString(s, m.Error())
+
+	case 'v':
+		m.writeSummary(s)
+		if attrs := renderAttrs(m.Attrs); len(attrs) > 0 {
+			var b strings.Builder
+			b.WriteString(" {")
+			writeAttrs(&b, attrs)
+			b.WriteString("}")
+			io.WriteString(s, b.String())
+		}
+		for _, e := range m.entries() {
+			var b strings.Builder
+			if ee, ok := e.err.(*Error); ok {
+				Rendering.plusV()(&b, ee)
+			} else {
+				fmt.Fprintf(&b, "%v", e.err)
+			}
+			if e.count > 1 {
+				b.WriteString(" (repeated " + strconv.Itoa(e.count) + " times)")
+			}
+			writeIndented(s, b.String())
+		}
+	}
+}
+
+// LogValue implements slog.LogValuer. The Multi is logged as a group with its
+// op, attrs and each collected error in an "errors" group keyed by position.
+func (m *Multi) LogValue() slog.Value {
+	return LogOptions{}.Value(m)
+}
+
+func (o LogOptions) multiValue(m *Multi) slog.Value {
+	var attrs []slog.Attr
+	if m.Op != "" {
+		attrs = append(attrs, slog.String("op", string(m.Op)))
+	}
+	if len(m.Attrs) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "attrs", Value: slog.GroupValue(renderAttrs(m.Attrs)...)})
+	}
+	var errs []slog.Attr
+	for i, e := range m.entries() {
+		errs = append(errs, slog.Attr{Key: strconv.Itoa(i), Value: o.Value(e.err)})
+	}
+	attrs = append(attrs, slog.Attr{Key: "errors", Value: slog.GroupValue(errs...)})
+	return slog.GroupValue(attrs...)
+}
+
+func (m *Multi) writeSummary(w io.Writer) {
+	if m.Op != "" {
+		io.WriteString(w, string(m.Op))
+		io.WriteString(w, ": ")
+	}
+	n := m.Len()
+	io.WriteString(w, strconv.Itoa(n))
+	if n == 1 {
+		io.WriteString(w, " error")
+	} else {
+		io.WriteString(w, " errors")
+	}
+}
+
+// writeIndented writes the text as a tree item, the first line marked
+// and the remaining lines indented beneath it.
+func writeIndented(w io.Writer, text string) {
+	sc := bufio.NewScanner(strings.NewReader(text))
+	first := true
+	for sc.Scan() {
+		if first {
+			io.WriteString(w, "\n  - ")
+			first = false
+		} else {
+			io.WriteString(w, "\n    ")
+		}
+		io.WriteString(w, sc.Text())
+	}
+}
+
+// identical reports whether a and b have the same message and root cause.
+func identical(a, b error) bool {
+	if equal(a, b) {
+		return true
+	}
+	return a.Error() == b.Error() && equal(rootCause(a), rootCause(b))
+}
+
+// equal compares errors with == when they are comparable, as errors.Is does.
+func equal(a, b error) bool {
+	return reflect.TypeOf(a).Comparable() && a == b
+}
+
+func rootCause(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
@@ -0,0 +1,103 @@
+package ctxerr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMulti(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		m := NewMulti(Op("pool"))
+		m.Add(nil)
+		if err := m.Err(); err != nil {
+			t.Errorf("Err() got %v wanted nil", err)
+		}
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		m := NewMulti(Op("pool"))
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m.Add(E(Op("worker"), io.ErrUnexpectedEOF))
+			}()
+		}
+		wg.Wait()
+		if n := m.Len(); n != 1 {
+			t.Errorf("Len() got %d wanted 1", n)
+		}
+		if !strings.Contains(fmt.Sprintf("%v", m), "(repeated 10 times)") {
+			t.Errorf("Format() did not report repeated error: %v", m)
+		}
+	})
+	t.Run("ErrorsIs", func(t *testing.T) {
+		m := NewMulti(Op("pool"))
+		m.Add(E(Op("a"), NotFound, io.EOF))
+		m.Add(errors.New("other"))
+
+		err := E(Op("outer"), m.Err())
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("errors.Is(err, io.EOF) got false wanted true")
+		}
+		if !errors.Is(err, NotFound) {
+			t.Errorf("errors.Is(err, NotFound) got false wanted true")
+		}
+		if errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("errors.Is(err, io.ErrClosedPipe) got true wanted false")
+		}
+	})
+	t.Run("Walkers", func(t *testing.T) {
+		m := NewMulti(Op("pool"))
+		m.Add(errors.New("plain"))
+		m.Add(E(Op("a"), NotFound, Temporary, slog.String("shard", "7"), io.EOF))
+
+		if got := KindOf(m); got != NotFound {
+			t.Errorf("KindOf() got %v wanted %v", got, NotFound)
+		}
+		if !IsTemporary(m) {
+			t.Errorf("IsTemporary() got false wanted true")
+		}
+		if v, ok := LookupAttr(m, "shard", Outermost); !ok || v.String() != "7" {
+			t.Errorf("LookupAttr() got %v, %t wanted 7", v, ok)
+		}
+	})
+	t.Run("Attrs", func(t *testing.T) {
+		m := NewMulti(Op("pool"), slog.String("job", "sync"))
+		m.Add(io.EOF)
+		err := E(Op("o"), m)
+
+		if v, ok := LookupAttr(err, "job", Outermost); !ok || v.String() != "sync" {
+			t.Errorf("LookupAttr() got %v, %t wanted sync", v, ok)
+		}
+		if got, want := fmt.Sprintf("%v", m), "pool: 1 error {job=sync}\n  - EOF"; got != want {
+			t.Errorf("Format() got %q wanted %q", got, want)
+		}
+		if got, want := m.LogValue().String(), "[op=pool attrs=[job=sync] errors=[0=EOF]]"; got != want {
+			t.Errorf("LogValue() got %q wanted %q", got, want)
+		}
+	})
+	t.Run("Format", func(t *testing.T) {
+		m := NewMulti(Op("pool"))
+		m.Add(E(Op("one"), "error", E(Op("two"), "error building foo", errors.New("concrete"))))
+		m.Add(errors.New("plain"))
+
+		if got, want := m.Error(), "pool: 2 errors: one: error: two: error building foo: concrete; plain"; got != want {
+			t.Errorf("Error() got %q wanted %q", got, want)
+		}
+		got := fmt.Sprintf("%v", m)
+		want := `pool: 2 errors
+  - one: error
+      two: error building foo
+        concrete
+  - plain`
+		if !strings.HasPrefix(got, want) {
+			t.Errorf("Format() got\n%s\nwanted prefix\n%s", got, want)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package ctxerr
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type autoOp bool
+
+// AutoOp is passed to E() to set the Op to the name of the calling function,
+// such as "ctxerr.T.Method". An Op argument takes precedence.
+const AutoOp = autoOp(true)
+
+// WithAutoOp sets the Op to the name of the function calling New, as AutoOp
+// does for E().
+func WithAutoOp() Option {
+	return func(o *options) { o.autoOp = true }
+}
+
+var opCache sync.Map // map[uintptr]Op
+
+// callerOp returns the Op for the function skip frames above the caller of
+// callerOp. Ops are cached by PC.
+func callerOp(skip int) Op {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return ""
+	}
+	pc := pcs[0]
+	if op, ok := opCache.Load(pc); ok {
+		return op.(Op)
+	}
+	var op Op
+	// The first frame is the function containing the PC, even if the call
+	// was inlined into another function.
+	if frames := resolveFrames(pcs[:]); len(frames) > 0 {
+		op = opName(frames[0].funcName)
+	}
+	opCache.Store(pc, op)
+	return op
+}
+
+// opName shortens a fully qualified function name to the package name and
+// function, e.g. "github.com/a/b/pkg.(*T).Method.func1" to "pkg.T.Method".
+func opName(fname string) Op {
+	// Reuse the stack trimming to drop the package path shared with the
+	// directory of the package.
+	if slash := strings.LastIndex(fname, "/"); slash >= 0 {
+		if trimmed, ok := trimPrev(fname[:slash+1], fname); ok {
+			fname = trimmed
+		}
+	}
+	fname = strings.NewReplacer("(*", "", ")", "").Replace(fname)
+	// Drop closure suffixes, which change as the code is edited.
+	for {
+		dot := strings.LastIndex(fname, ".")
+		if dot < 0 || !isClosure(fname[dot+1:]) {
+			break
+		}
+		fname = fname[:dot]
+	}
+	return Op(fname)
+}
+
+// isClosure reports whether the element of a function name is generated
+// for a function literal, such as "func1", or a nested literal such as "1".
+func isClosure(s string) bool {
+	s = strings.TrimPrefix(s, "func")
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package ctxerr
+
+import (
+	"errors"
+	"testing"
+)
+
+type opT struct{}
+
+func (*opT) method() error { return E(AutoOp, "msg") }
+
+func autoOpFunc() error { return E(AutoOp, "msg") }
+
+func autoOpNew() error { return New(WithAutoOp(), WithMsg("msg")) }
+
+func autoOpClosure() error {
+	f := func() error { return Ef(AutoOp, "msg %d", 1) }
+	return f()
+}
+
+func autoOpPanic() (err error) {
+	defer Recover(&err, AutoOp)
+	panicker()
+	return nil
+}
+
+func panicker() { panic("boom") }
+
+func TestAutoOp(t *testing.T) {
+	cases := []struct {
+		name string
+		fn   func() error
+		want Op
+	}{
+		{"Func", autoOpFunc, "ctxerr.autoOpFunc"},
+		{"Method", new(opT).method, "ctxerr.opT.method"},
+		{"Closure", autoOpClosure, "ctxerr.autoOpClosure"},
+		{"New", autoOpNew, "ctxerr.autoOpNew"},
+		{"Explicit", func() error { return E(AutoOp, Op("explicit")) }, "explicit"},
+		{"Recover", autoOpPanic, "ctxerr.panicker"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Twice, to use the cached Op.
+			for range 2 {
+				var e *Error
+				if !errors.As(tc.fn(), &e) {
+					t.Fatalf("got %T wanted *Error", e)
+				}
+				if e.Op != tc.want {
+					t.Errorf("Op got %q wanted %q", e.Op, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOpName(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Op
+	}{
+		{"main.main", "main.main"},
+		{"github.com/a/b/pkg.F", "pkg.F"},
+		{"github.com/a/b/pkg.(*T).Method", "pkg.T.Method"},
+		{"github.com/a/b/pkg.T.Method.func1", "pkg.T.Method"},
+		{"github.com/a/b/pkg.F.func1.2", "pkg.F"},
+		{"github.com/a/b/pkg.Gen[...]", "pkg.Gen[...]"},
+	}
+	for _, tc := range cases {
+		if got := opName(tc.in); got != tc.want {
+			t.Errorf("opName(%q) got %q wanted %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func BenchmarkAutoOp(b *testing.B) {
+	for range b.N {
+		_ = E(AutoOp, NoStack, "msg")
+	}
+}
@@ -0,0 +1,120 @@
+package ctxerr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Option configures the *Error created by New.
+type Option func(*options)
+
+type options struct {
+	e      Error
+	ctxs   []context.Context
+	policy *CapturePolicy
+	autoOp bool
+}
+
+// WithOp sets the operation.
+func WithOp(op Op) Option {
+	return func(o *options) { o.e.Op = op }
+}
+
+// WithKind sets the classification of the error.
+func WithKind(k Kind) Option {
+	return func(o *options) { o.e.Kind = k }
+}
+
+// WithMsg sets the message.
+func WithMsg(msg string) Option {
+	return func(o *options) { o.e.Msg = msg }
+}
+
+// WithMsgf sets the message formatted with fmt.Sprintf.
+func WithMsgf(format string, args ...any) Option {
+	return func(o *options) { o.e.Msg = fmt.Sprintf(format, args...) }
+}
+
+// WithCause sets the cause. A nil cause, including a nil *Error, is ignored.
+func WithCause(err error) Option {
+	return func(o *options) {
+		if err == nil {
+			return
+		}
+		if ee, ok := err.(*Error); ok {
+			if ee == nil {
+				return
+			}
+			// Make a copy
+			copyErr := *ee
+			err = &copyErr
+		}
+		o.e.Err = err
+	}
+}
+
+// WithContext adds the attributes of the context, see AttrExtractor. A nil context is
+// ignored.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		if ctx != nil {
+			o.ctxs = append(o.ctxs, ctx)
+		}
+	}
+}
+
+// WithAttrs adds the attributes.
+func WithAttrs(attrs ...slog.Attr) Option {
+	return func(o *options) { o.e.Attrs = append(o.e.Attrs, attrs...) }
+}
+
+// WithTemporary marks the error as safe to retry.
+func WithTemporary() Option {
+	return func(o *options) { o.e.Temporary = true }
+}
+
+// WithCapturePolicy overrides the stack capture policy for this error.
+func WithCapturePolicy(p CapturePolicy) Option {
+	return func(o *options) { o.policy = &p }
+}
+
+// New returns a new *Error configured by the options. Unlike E, the
+// arguments are checked by the compiler, and New never panics. Nil options
+// are ignored.
+func New(opts ...Option) error {
+	return newFromOptions(opts)
+}
+
+func newFromOptions(opts []Option) *Error {
+	var o options
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	policy := currentCapturePolicy()
+	if o.policy != nil {
+		policy = *o.policy
+	}
+	e := &o.e
+	if policy.Mode != CaptureLite {
+		var ctxAttrs []slog.Attr
+		for _, ctx := range o.ctxs {
+			ctxAttrs = append(ctxAttrs, ContextAttrs(ctx)...)
+		}
+		e.Attrs = append(ctxAttrs, e.Attrs...)
+	}
+	if o.autoOp && e.Op == "" {
+		// skip newFromOptions and New
+		e.Op = callerOp(2)
+	}
+	e.redactCaptured()
+	var ctx context.Context
+	if len(o.ctxs) > 0 {
+		ctx = o.ctxs[0]
+	}
+	e.captureOrigin(ctx, policy)
+	e.populateStack(policy)
+	return e
+}
@@ -0,0 +1,95 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"github.com/cyrusaf/ctxlog"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(), slog.String("user", "bob"))
+	err := New(
+		WithOp("load"),
+		WithKind(NotFound),
+		WithMsgf("file %q", "a.txt"),
+		WithCause(io.EOF),
+		WithContext(ctx),
+		WithAttrs(slog.Int("size", 3)),
+		WithTemporary(),
+	)
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("New() got %T wanted *Error", err)
+	}
+	if e.Op != "load" || e.Kind != NotFound || e.Msg != `file "a.txt"` || !e.Temporary {
+		t.Errorf("New() got %+v", *e)
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("errors.Is(io.EOF) got false wanted true")
+	}
+	want := []slog.Attr{slog.String("user", "bob"), slog.Int("size", 3)}
+	if diff := cmp.Diff(want, e.Attrs, cmpAttrs); diff != "" {
+		t.Errorf("New() attrs mismatch (-want +got):\n%s", diff)
+	}
+	if st := e.StackTrace(); len(st) == 0 || !strings.HasSuffix(st[0].Function, "ctxerr.TestNew") {
+		t.Errorf("New() stack got %v", st)
+	}
+}
+
+func TestNewNil(t *testing.T) {
+	err := New(WithOp("op"), WithCause(nil), WithContext(nil), nil)
+	e := err.(*Error)
+	if e.Err != nil || len(e.Attrs) != 0 {
+		t.Errorf("New() got %+v wanted no cause or attrs", *e)
+	}
+	if e := New(WithOp("op"), WithCause((*Error)(nil))).(*Error); e.Err != nil {
+		t.Errorf("New(WithCause(nil *Error)) got cause %v wanted nil", e.Err)
+	}
+}
+
+func TestNewCapturePolicy(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(), slog.String("user", "bob"))
+	e := New(WithCapturePolicy(Lite), WithContext(ctx)).(*Error)
+	if e.hasStack() || len(e.Attrs) != 0 {
+		t.Errorf("New(Lite) got stack %v attrs %v", e.hasStack(), e.Attrs)
+	}
+}
+
+func TestEBadArgs(t *testing.T) {
+	var cause error
+	e := E(Op("op"), cause, 42).(*Error)
+	if e.Err != nil {
+		t.Errorf("E(nil) got cause %v wanted nil", e.Err)
+	}
+	if e := E(Op("a"), (*Error)(nil)).(*Error); e.Err != nil {
+		t.Errorf("E(nil *Error) got cause %v wanted nil", e.Err)
+	}
+	want := []slog.Attr{slog.Int(badKey, 42)}
+	if diff := cmp.Diff(want, e.Attrs, cmpAttrs); diff != "" {
+		t.Errorf("E() attrs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEf(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"FormatFirst", Ef("read %d bytes", 3), "read 3 bytes"},
+		{"FormatAfterOp", Ef(Op("op"), "read %d bytes", 3), "op: read 3 bytes"},
+		{"NoArgs", Ef("plain"), "plain"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.err.Error(); got != tc.want {
+				t.Errorf("Ef() got %q wanted %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package ctxerr
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"log/slog"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Origin records where and when an *Error was created. It is only captured
+// when enabled by the CapturePolicy, see CapturePolicy.Goroutine,
+// CapturePolicy.Labels and CapturePolicy.Time.
+type Origin struct {
+	// Time is when the error was created.
+	Time time.Time
+	// Goroutine is the ID of the goroutine that created the error, or zero
+	// if not captured.
+	Goroutine int64
+	// Labels are the pprof labels of the context passed to E(), sorted by
+	// key, such as those set with pprof.Do for a worker.
+	Labels []slog.Attr
+}
+
+// captureOrigin sets the Origin of e as selected by the policy. ctx may be
+// nil.
+func (e *Error) captureOrigin(ctx context.Context, p CapturePolicy) {
+	if p.Mode == CaptureLite || !(p.Time || p.Goroutine || p.Labels) {
+		return
+	}
+	o := &Origin{}
+	if p.Time {
+		o.Time = time.Now()
+	}
+	if p.Goroutine {
+		o.Goroutine = goroutineID()
+	}
+	if p.Labels && ctx != nil {
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			o.Labels = append(o.Labels, slog.String(key, value))
+			return true
+		})
+		slices.SortFunc(o.Labels, func(a, b slog.Attr) int { return cmp.Compare(a.Key, b.Key) })
+	}
+	e.Origin = o
+}
+
+// attrs returns the captured fields of the Origin.
+func (o *Origin) attrs() []slog.Attr {
+	if o == nil {
+		return nil
+	}
+	var attrs []slog.Attr
+	if !o.Time.IsZero() {
+		attrs = append(attrs, slog.Time("time", o.Time))
+	}
+	if o.Goroutine != 0 {
+		attrs = append(attrs, slog.Int64("goroutine", o.Goroutine))
+	}
+	if len(o.Labels) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "labels", Value: slog.GroupValue(o.Labels...)})
+	}
+	return attrs
+}
+
+// goroutineID returns the ID of the current goroutine, parsed from the
+// header of its stack, "goroutine 18 [running]:".
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
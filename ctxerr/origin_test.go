@@ -0,0 +1,70 @@
+package ctxerr
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"log/slog"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrigin(t *testing.T) {
+	policy := CapturePolicy{Goroutine: true, Labels: true, Time: true}
+	start := time.Now()
+	done := make(chan *Error)
+	go func() {
+		labels := pprof.Labels("worker", "3", "pool", "fetch")
+		pprof.Do(context.Background(), labels, func(ctx context.Context) {
+			done <- E(Op("work"), ctx, policy, "failed").(*Error)
+		})
+	}()
+	e := <-done
+
+	o := e.Origin
+	if o == nil {
+		t.Fatalf("Origin got nil wanted captured")
+	}
+	if o.Goroutine == 0 || o.Goroutine == goroutineID() {
+		t.Errorf("Goroutine got %d wanted the worker goroutine", o.Goroutine)
+	}
+	if o.Time.Before(start) || o.Time.After(time.Now()) {
+		t.Errorf("Time got %v wanted after %v", o.Time, start)
+	}
+	want := []slog.Attr{slog.String("pool", "fetch"), slog.String("worker", "3")}
+	if diff := cmp.Diff(want, o.Labels, cmpAttrs); diff != "" {
+		t.Errorf("Labels got diff -want/+got: %s", diff)
+	}
+
+	goroutine := fmt.Sprintf("goroutine=%d", o.Goroutine)
+	if got := fmt.Sprintf("%+v", e); !strings.Contains(got, "labels=[pool=fetch worker=3]") || !strings.Contains(got, goroutine) {
+		t.Errorf("%%+v got %q wanted origin", got)
+	}
+	if got := e.LogValue().String(); !strings.Contains(got, "origin=[") || !strings.Contains(got, goroutine) {
+		t.Errorf("LogValue() got %q wanted origin", got)
+	}
+}
+
+func TestOriginOff(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{"Default", E(Op("op"), context.Background(), "msg")},
+		{"Lite", E(Op("op"), CapturePolicy{Mode: CaptureLite, Time: true}, "msg")},
+	}
+	for _, tc := range cases {
+		if o := tc.err.(*Error).Origin; o != nil {
+			t.Errorf("%s: Origin got %+v wanted nil", tc.name, o)
+		}
+	}
+}
+
+func TestNewOrigin(t *testing.T) {
+	e := New(WithOp("op"), WithCapturePolicy(CapturePolicy{Goroutine: true})).(*Error)
+	if e.Origin == nil || e.Origin.Goroutine != goroutineID() || !e.Origin.Time.IsZero() {
+		t.Errorf("New() Origin got %+v wanted only this goroutine", e.Origin)
+	}
+}
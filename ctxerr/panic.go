@@ -0,0 +1,137 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PanicError is the cause of an *Error created from a recovered panic whose
+// value was not an error.
+type PanicError struct {
+	Value any
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// PanicPolicy controls what Recover does after recording a panic.
+type PanicPolicy int
+
+const (
+	// Capture stops the panic and reports it as an error.
+	Capture PanicPolicy = iota
+	// Repanic records the error and then panics again with the *Error.
+	Repanic
+)
+
+// Recover converts a panic into an *Error stored in *errp. It must be called
+// directly by defer:
+//
+//	func handle(ctx context.Context) (err error) {
+//		defer ctxerr.Recover(&err, ctxerr.Op("handle"), ctx)
+//		...
+//	}
+//
+// The remaining arguments are interpreted as they are by E(), and a
+// PanicPolicy argument selects whether to panic again. The *Error has Kind
+// Internal unless a Kind is passed, records the panic value (as the cause if
+// it is an error, otherwise as a *PanicError) and, subject to the
+// CapturePolicy, the stack of the panicking goroutine. If *errp already holds
+// an error it is replaced. AutoOp sets the Op to the function that panicked.
+//
+// If errp is nil the error cannot be returned, so Recover panics again with
+// the *Error whatever the PanicPolicy.
+func Recover(errp *error, args ...any) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	e, policy := panicError(r, args)
+	if errp == nil {
+		panic(e)
+	}
+	*errp = e
+	if policy == Repanic {
+		panic(e)
+	}
+}
+
+// Go calls fn in a new goroutine, converting a panic into an error as Recover
+// does. The result is sent on the returned channel, which is then closed.
+func Go(ctx context.Context, fn func(context.Context) error, args ...any) <-chan error {
+	out := make(chan error, 1)
+	go func() {
+		defer close(out)
+		out <- call(ctx, fn, args)
+	}()
+	return out
+}
+
+func call(ctx context.Context, fn func(context.Context) error, args []any) (err error) {
+	defer Recover(&err, append([]any{ctx}, args...)...)
+	return fn(ctx)
+}
+
+func panicError(r any, args []any) (*Error, PanicPolicy) {
+	var policy PanicPolicy
+	var auto autoOp
+	var eargs []any
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case PanicPolicy:
+			policy = arg
+			continue
+		case autoOp:
+			auto = arg
+			continue
+		}
+		eargs = append(eargs, arg)
+	}
+	cause, ok := r.(error)
+	if !ok {
+		cause = &PanicError{Value: r}
+	}
+	// Internal comes first so that a Kind passed by the caller replaces it.
+	e, capture := buildError(append(append([]any{Internal}, eargs...), cause))
+	var inner *Error
+	if !errors.As(cause, &inner) && capture.captureStack(e.Kind) {
+		e.stack = panicStack(capture.maxDepth())
+	}
+	if auto && e.Op == "" {
+		// The caller of Recover is the runtime, so use the function that
+		// panicked, even when the policy captured no stack.
+		stk := e.stack
+		if len(stk.callers) == 0 {
+			stk = panicStack(defaultMaxDepth)
+		}
+		if frames := stk.allFrames(); len(frames) > 0 {
+			e.Op = opName(frames[0].funcName)
+		}
+	}
+	return e, policy
+}
+
+// panicStack returns the stack of the panicking goroutine starting at the
+// frame that panicked, with at most depth PCs including the runtime frames
+// that are dropped.
+func panicStack(depth int) stack {
+	// skip runtime.Callers, callersDepth, panicStack, panicError and Recover.
+	pcs := callersDepth(5, depth).callers
+	// Drop the runtime frames between the deferred call and the panic.
+	for len(pcs) > 1 && isRuntime(resolveFrames(pcs[:1])) {
+		pcs = pcs[1:]
+	}
+	return stack{callers: pcs}
+}
+
+func isRuntime(frames []frame) bool {
+	for _, f := range frames {
+		if !strings.HasPrefix(f.funcName, "runtime.") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"github.com/cyrusaf/ctxlog"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+//go:noinline
+func panicky(ctx context.Context, v any) (err error) {
+	defer Recover(&err, Op("panicky"), ctx)
+	panicHere(v)
+	return nil
+}
+
+//go:noinline
+func panicHere(v any) {
+	panic(v)
+}
+
+func TestRecover(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(), slog.String("call", "lookup"))
+
+	t.Run("Value", func(t *testing.T) {
+		err := panicky(ctx, "boom")
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Fatalf("Recover() got %v wanted *Error", err)
+		}
+		if e.Op != "panicky" || e.Kind != Internal {
+			t.Errorf("Recover() got Op %q Kind %v wanted %q %v", e.Op, e.Kind, "panicky", Internal)
+		}
+		var p *PanicError
+		if !errors.As(err, &p) || p.Value != "boom" {
+			t.Errorf("Recover() got cause %v wanted PanicError{boom}", e.Err)
+		}
+		if v, ok := LookupAttr(err, "call", Outermost); !ok || v.String() != "lookup" {
+			t.Errorf("Recover() got attr call=%v wanted %q", v, "lookup")
+		}
+		st := e.StackTrace()
+		if len(st) == 0 || st[0].Function != "github.com/nveeser/srvsrv/ctxerr.panicHere" {
+			t.Errorf("Recover() got stack %v wanted panicHere first", st)
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		err := panicky(ctx, io.EOF)
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Recover() got %v wanted cause io.EOF", err)
+		}
+	})
+	t.Run("NoPanic", func(t *testing.T) {
+		err := func() (err error) {
+			defer Recover(&err)
+			return io.EOF
+		}()
+		if err != io.EOF {
+			t.Errorf("Recover() got %v wanted io.EOF", err)
+		}
+	})
+	t.Run("Repanic", func(t *testing.T) {
+		var recorded error
+		defer func() {
+			r := recover()
+			if r == nil || r != recorded {
+				t.Errorf("Repanic got panic %v wanted %v", r, recorded)
+			}
+		}()
+		defer Recover(&recorded, Op("repanic"), Repanic)
+		panic("boom")
+	})
+}
+
+func TestRecoverOptions(t *testing.T) {
+	recovered := func(args ...any) *Error {
+		var err error
+		func() {
+			defer Recover(&err, args...)
+			panicHere("boom")
+		}()
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Fatalf("Recover() got %v wanted *Error", err)
+		}
+		return e
+	}
+
+	t.Run("Kind", func(t *testing.T) {
+		if e := recovered(Op("kind"), Unavailable); e.Kind != Unavailable {
+			t.Errorf("Recover() got Kind %v wanted %v", e.Kind, Unavailable)
+		}
+	})
+	t.Run("NoStack", func(t *testing.T) {
+		if st := recovered(NoStack).StackTrace(); len(st) != 0 {
+			t.Errorf("Recover(NoStack) got stack %v wanted none", st)
+		}
+	})
+	t.Run("AutoOpNoStack", func(t *testing.T) {
+		if e := recovered(Lite, AutoOp); e.Op != "ctxerr.panicHere" {
+			t.Errorf("Recover(Lite, AutoOp) got Op %q wanted %q", e.Op, "ctxerr.panicHere")
+		}
+	})
+	t.Run("MaxDepth", func(t *testing.T) {
+		if st := recovered(CapturePolicy{MaxDepth: 3}).StackTrace(); len(st) == 0 || len(st) > 3 {
+			t.Errorf("Recover(MaxDepth: 3) got %d frames wanted 1 to 3", len(st))
+		}
+	})
+	t.Run("NilErrp", func(t *testing.T) {
+		defer func() {
+			var e *Error
+			if r, _ := recover().(error); !errors.As(r, &e) || e.Op != "nil" {
+				t.Errorf("Recover(nil) got panic %v wanted *Error with Op %q", r, "nil")
+			}
+		}()
+		defer Recover(nil, Op("nil"))
+		panicHere("boom")
+	})
+}
+
+func TestGo(t *testing.T) {
+	err := <-Go(context.Background(), func(context.Context) error {
+		panic("boom")
+	}, Op("worker"))
+	var e *Error
+	if !errors.As(err, &e) || e.Op != "worker" {
+		t.Errorf("Go() got %v wanted *Error with Op %q", err, "worker")
+	}
+
+	err = <-Go(context.Background(), func(context.Context) error { return io.EOF })
+	if err != io.EOF {
+		t.Errorf("Go() got %v wanted io.EOF", err)
+	}
+}
@@ -0,0 +1,71 @@
+package ctxerr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Prefix lists the keys of the context attributes that ContextError and
+// Errorf add to an error as its Prefix. Use a Prefix value to select the keys
+// for a single call:
+//
+//	ctxerr.Prefix{"tenant", "call"}.Errorf(ctx, "lookup failed: %w", err)
+type Prefix []string
+
+// DefaultPrefix is the Prefix used by the package level ContextError and
+// Errorf functions.
+func DefaultPrefix() Prefix {
+	return Prefix{"module", "call", "action", "error"}
+}
+
+// ContextError returns an *Error wrapping err with the DefaultPrefix
+// attributes found in ctx.
+func ContextError(ctx context.Context, err error) error {
+	return DefaultPrefix().newError(ctx, err)
+}
+
+// Errorf returns an *Error wrapping fmt.Errorf(format, args...) with the
+// DefaultPrefix attributes found in ctx. As with fmt.Errorf, the %w verb
+// wraps its argument.
+func Errorf(ctx context.Context, format string, args ...any) error {
+	return DefaultPrefix().newError(ctx, fmt.Errorf(format, args...))
+}
+
+// ContextError returns an *Error wrapping err with the attributes of ctx
+// selected by p.
+func (p Prefix) ContextError(ctx context.Context, err error) error {
+	return p.newError(ctx, err)
+}
+
+// Errorf returns an *Error wrapping fmt.Errorf(format, args...) with the
+// attributes of ctx selected by p.
+func (p Prefix) Errorf(ctx context.Context, format string, args ...any) error {
+	return p.newError(ctx, fmt.Errorf(format, args...))
+}
+
+// newError must be called directly by the exported functions so that the
+// stack is captured from their caller.
+func (p Prefix) newError(ctx context.Context, err error) *Error {
+	e := &Error{Err: err, Prefix: p.attrs(ContextAttrs(ctx))}
+	e.redactCaptured()
+	policy := currentCapturePolicy()
+	e.captureOrigin(ctx, policy)
+	e.populateStack(policy)
+	return e
+}
+
+// attrs returns the attributes whose key is in p, in the order of p. If a
+// key is present more than once the last value is used.
+func (p Prefix) attrs(attrs []slog.Attr) []slog.Attr {
+	var out []slog.Attr
+	for _, key := range p {
+		for i := len(attrs) - 1; i >= 0; i-- {
+			if attrs[i].Key == key {
+				out = append(out, attrs[i])
+				break
+			}
+		}
+	}
+	return out
+}
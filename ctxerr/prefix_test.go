@@ -0,0 +1,64 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"github.com/cyrusaf/ctxlog"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestContextError(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(),
+		slog.String("module", "db"),
+		slog.String("user", "bob"),
+		slog.Int("call", 3))
+
+	err := ContextError(ctx, io.EOF)
+	if got, want := err.Error(), "[db][3]: EOF"; got != want {
+		t.Errorf("ContextError() got %q wanted %q", got, want)
+	}
+	if !errors.Is(err, io.EOF) {
+		t.Errorf("errors.Is(err, io.EOF) got false wanted true")
+	}
+	e := err.(*Error)
+	want := []slog.Attr{slog.String("module", "db"), slog.Int("call", 3)}
+	if diff := cmp.Diff(want, e.Prefix, cmpAttrs); diff != "" {
+		t.Errorf("ContextError() got Prefix diff -want/+got: %s", diff)
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	ctx := ctxlog.WithAttrs(context.Background(),
+		slog.String("module", "db"),
+		slog.Duration("action", time.Second),
+		slog.String("tenant", "acme"))
+
+	t.Run("Default", func(t *testing.T) {
+		err := Errorf(ctx, "lookup %s: %w", "key", io.EOF)
+		if got, want := err.Error(), "[db][1s]: lookup key: EOF"; got != want {
+			t.Errorf("Errorf() got %q wanted %q", got, want)
+		}
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("errors.Is(err, io.EOF) got false wanted true")
+		}
+		if !err.(*Error).hasStack() {
+			t.Errorf("Errorf() got no stack")
+		}
+	})
+	t.Run("PerCallKeys", func(t *testing.T) {
+		err := Prefix{"tenant"}.Errorf(ctx, "lookup %d", 3)
+		if got, want := err.Error(), "[acme]: lookup 3"; got != want {
+			t.Errorf("Errorf() got %q wanted %q", got, want)
+		}
+	})
+	t.Run("NoAttrs", func(t *testing.T) {
+		err := Errorf(context.Background(), "plain")
+		if got, want := err.Error(), "plain"; got != want {
+			t.Errorf("Errorf() got %q wanted %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package ctxerr
+
+import (
+	"github.com/nveeser/srvsrv/redact"
+	"log/slog"
+	"sync/atomic"
+)
+
+var redaction atomic.Pointer[redact.Policy]
+
+// SetRedaction sets the policy that removes sensitive attributes, including
+// the Prefix, from errors. Depending on the Stage of the policy, attributes
+// are redacted once when an error is created, or each time an error is
+// formatted, serialized or logged. Values wrapped in a redact.Secret are
+// always masked, even without a policy.
+//
+// With the redact.Capture stage only errors created by E(), Ef(), New and
+// Errorf, or decoded by FromWire, are redacted. An Error built as a struct
+// literal, or whose attributes are changed after it is created, is rendered
+// as is.
+func SetRedaction(p *redact.Policy) {
+	redaction.Store(p)
+}
+
+// redactCaptured redacts the attributes of a new error if the policy applies
+// at capture time.
+func (e *Error) redactCaptured() {
+	if p := redaction.Load(); p != nil && p.Stage == redact.Capture {
+		e.Attrs = p.Attrs(e.Attrs)
+		e.Prefix = p.Attrs(e.Prefix)
+	}
+}
+
+// redactCaptured redacts the attributes of a decoded Multi, as for an *Error.
+func (m *Multi) redactCaptured() {
+	if p := redaction.Load(); p != nil && p.Stage == redact.Capture {
+		m.Attrs = p.Attrs(m.Attrs)
+	}
+}
+
+// renderAttrs returns the attributes to render, redacted unless the policy
+// was applied at capture time.
+func renderAttrs(attrs []slog.Attr) []slog.Attr {
+	p := redaction.Load()
+	if p != nil && p.Stage == redact.Capture {
+		return attrs
+	}
+	return p.Attrs(attrs)
+}
@@ -0,0 +1,79 @@
+package ctxerr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cyrusaf/ctxlog"
+	"github.com/nveeser/srvsrv/redact"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	defer SetRedaction(nil)
+	ctx := ctxlog.WithAttrs(context.Background(),
+		slog.String("module", "db"),
+		slog.String("token", "tok-123"))
+	newErr := func() *Error {
+		return E(Op("load"), ctx, slog.Any("card", redact.NewSecret("4111")), "failed").(*Error)
+	}
+	leaks := func(t *testing.T, e *Error, secrets ...string) {
+		t.Helper()
+		b, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("Marshal() got error: %s", err)
+		}
+		outputs := map[string]string{
+			"%+v":        fmt.Sprintf("%+v", e),
+			"json":       string(b),
+			"LogValue":   e.LogValue().String(),
+			"ChainAttrs": fmt.Sprint(ChainAttrs(e, Outermost)),
+		}
+		for name, out := range outputs {
+			for _, secret := range secrets {
+				if strings.Contains(out, secret) {
+					t.Errorf("%s got %s wanted %q redacted", name, out, secret)
+				}
+			}
+		}
+	}
+
+	t.Run("Secret", func(t *testing.T) {
+		// Secrets are masked without a policy.
+		leaks(t, newErr(), "4111")
+	})
+	t.Run("Render", func(t *testing.T) {
+		SetRedaction(&redact.Policy{Keys: []string{"token"}})
+		e := newErr()
+		leaks(t, e, "tok-123", "4111")
+		// The captured attributes are intact.
+		if v, ok := attrValue(e.Attrs, "token"); !ok || v != "tok-123" {
+			t.Errorf("Attrs got token=%q wanted %q", v, "tok-123")
+		}
+	})
+	t.Run("Capture", func(t *testing.T) {
+		SetRedaction(&redact.Policy{Keys: []string{"token"}, Stage: redact.Capture})
+		e := newErr()
+		leaks(t, e, "tok-123", "4111")
+		if v, ok := attrValue(e.Attrs, "token"); !ok || v != redact.Mask {
+			t.Errorf("Attrs got token=%q wanted %q", v, redact.Mask)
+		}
+	})
+	t.Run("CaptureFromWire", func(t *testing.T) {
+		SetRedaction(&redact.Policy{Keys: []string{"token"}, Stage: redact.Capture})
+		// A peer without a policy sends the token.
+		w := &WireError{Op: "load", Attrs: []WireAttr{{Key: "token", Kind: "String", Value: "tok-123"}}}
+		leaks(t, FromWire(w).(*Error), "tok-123")
+	})
+}
+
+func attrValue(attrs []slog.Attr, key string) (string, bool) {
+	for _, a := range attrs {
+		if a.Key == key {
+			return a.Value.String(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,240 @@
+package ctxerr
+
+import (
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Renderer writes an *Error and its chain of causes.
+type Renderer func(w io.Writer, e *Error)
+
+// Verbs selects the Renderer used for each fmt verb.
+type Verbs struct {
+	// S renders %s, %q and %w, and Error().
+	S Renderer
+	// V renders %v.
+	V Renderer
+	// PlusV renders %+v.
+	PlusV Renderer
+}
+
+// Rendering holds the Renderer used for each verb. A nil Renderer is replaced
+// by the default:
+//
+//   - %s, %q, %w and Error() use SingleLine, as errors wrapped with
+//     fmt.Errorf do
+//   - %v uses SingleLine, so that %v and %s agree as they do for other errors
+//   - %+v uses Tree, which adds the attributes and the stack
+var Rendering = Verbs{S: SingleLine, V: SingleLine, PlusV: Tree}
+
+func (v Verbs) s() Renderer     { return orDefault(v.S, SingleLine) }
+func (v Verbs) v() Renderer     { return orDefault(v.V, SingleLine) }
+func (v Verbs) plusV() Renderer { return orDefault(v.PlusV, Tree) }
+
+func orDefault(r, def Renderer) Renderer {
+	if r == nil {
+		return def
+	}
+	return r
+}
+
+// chain returns each *Error in the chain of e, outermost first, and the first
+// error in the chain that is not an *Error, if any.
+func (e *Error) chain() (levels []*Error, root error) {
+	var curr error = e
+	for curr != nil {
+		ee, ok := curr.(*Error)
+		if !ok {
+			return levels, curr
+		}
+		levels = append(levels, ee)
+		curr = ee.Err
+	}
+	return levels, nil
+}
+
+// prefixString returns the Prefix values as "[a][b]".
+func (e *Error) prefixString() string {
+	var b strings.Builder
+	for _, attr := range renderAttrs(e.Prefix) {
+		b.WriteString("[")
+		b.WriteString(attr.Value.Resolve().String())
+		b.WriteString("]")
+	}
+	return b.String()
+}
+
+// parts returns the prefix, Op and message of e, omitting those not set.
+func (e *Error) parts() []string {
+	var parts []string
+	if p := e.prefixString(); p != "" {
+		parts = append(parts, p)
+	}
+	if e.Op != "" {
+		parts = append(parts, string(e.Op))
+	}
+	if e.Msg != "" {
+		parts = append(parts, e.Msg)
+	}
+	return parts
+}
+
+// SingleLine renders the chain as errors wrapped with fmt.Errorf are: the
+// prefix, Op and message of each *Error followed by the cause, separated by
+// ": ", e.g. "[db]: load: reading config: read: EOF".
+func SingleLine(w io.Writer, e *Error) {
+	levels, root := e.chain()
+	var parts []string
+	for _, ee := range levels {
+		parts = append(parts, ee.parts()...)
+	}
+	if root != nil {
+		parts = append(parts, root.Error())
+	}
+	writeParts(w, parts)
+}
+
+// Compact renders the prefix and the Ops of the chain followed by only the
+// innermost message, e.g. "[db]: load: read: EOF". The innermost message is
+// that of the cause, or of the innermost *Error with a message if the chain
+// ends with an *Error.
+func Compact(w io.Writer, e *Error) {
+	levels, root := e.chain()
+	var parts []string
+	var msg string
+	for _, ee := range levels {
+		if p := ee.prefixString(); p != "" {
+			parts = append(parts, p)
+		}
+		if ee.Op != "" {
+			parts = append(parts, string(ee.Op))
+		}
+		if ee.Msg != "" {
+			msg = ee.Msg
+		}
+	}
+	if root != nil {
+		msg = root.Error()
+	}
+	if msg != "" {
+		parts = append(parts, msg)
+	}
+	writeParts(w, parts)
+}
+
+func writeParts(w io.Writer, parts []string) {
+	if len(parts) == 0 {
+		io.WriteString(w, "ctxerr.Error")
+		return
+	}
+	io.WriteString(w, strings.Join(parts, ": "))
+}
+
+// Tree renders each error in the chain on its own line, indented beneath the
+// error that wraps it, followed by the stack of the innermost *Error. Each
+// *Error is rendered as its prefix, Op and message, followed by its Kind,
+// Temporary and attributes in braces:
+//
+//	load: reading config {kind="not found" user=bob}
+//	  read
+//	    EOF
+//		/src/config.go:12
+//		   example.com/config.Read(...)
+//
+// An *Error with nothing to render is omitted.
+func Tree(w io.Writer, e *Error) {
+	levels, root := e.chain()
+	var written bool
+	var depth int
+	writeLine := func(s string) {
+		if written {
+			io.WriteString(w, "\n")
+		}
+		io.WriteString(w, strings.Repeat("  ", depth))
+		io.WriteString(w, s)
+		written = true
+		depth++
+	}
+	for _, ee := range levels {
+		line := strings.Join(ee.parts(), ": ")
+		if attrs := ee.treeAttrs(); attrs != "" {
+			if line != "" {
+				line += " "
+			}
+			line += "{" + attrs + "}"
+		}
+		if line != "" {
+			writeLine(line)
+		}
+	}
+	if root != nil {
+		writeLine(root.Error())
+	}
+	if !written {
+		writeLine("ctxerr.Error")
+	}
+	if stacked := stackedError(e); stacked != nil {
+		stacked.writeStack(w, written)
+	}
+}
+
+// treeAttrs returns the Kind, Temporary, attributes and Origin of e as
+// "key=value" separated by spaces. Groups are rendered as "key=[a=1 b=2]".
+func (e *Error) treeAttrs() string {
+	attrs := renderAttrs(e.Attrs)
+	if e.Temporary {
+		attrs = append([]slog.Attr{slog.Bool("temporary", true)}, attrs...)
+	}
+	if e.Kind != Other {
+		attrs = append([]slog.Attr{slog.String("kind", e.Kind.String())}, attrs...)
+	}
+	if origin := e.Origin.attrs(); len(origin) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "origin", Value: slog.GroupValue(origin...)})
+	}
+	var b strings.Builder
+	writeAttrs(&b, attrs)
+	return b.String()
+}
+
+func writeAttrs(b *strings.Builder, attrs []slog.Attr) {
+	for i, a := range attrs {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(a.Key)
+		b.WriteString("=")
+		v := a.Value.Resolve()
+		if v.Kind() == slog.KindGroup {
+			b.WriteString("[")
+			writeAttrs(b, v.Group())
+			b.WriteString("]")
+			continue
+		}
+		b.WriteString(quoteValue(v.String()))
+	}
+}
+
+// quoteValue quotes values that would be ambiguous unquoted, as
+// slog.TextHandler does.
+func quoteValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"\\[]{}") || !strconv.CanBackquote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// Bracketed renders the Op of an *Error in brackets, followed by its message
+// and the summary of its cause, e.g. "[load] : reading config[read] ". It is
+// the rendering of %s used before Renderers were added.
+func Bracketed(w io.Writer, e *Error) {
+	e.writeSummary(w, true)
+}
+
+// BracketedChain renders each error in the chain as Bracketed does, on its own
+// line, followed by the stack of the innermost *Error. It is the rendering of
+// %v used before Renderers were added.
+func BracketedChain(w io.Writer, e *Error) {
+	e.writeChain(w)
+}
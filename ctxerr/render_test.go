@@ -0,0 +1,107 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/cyrusaf/ctxlog"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestRender checks the rendering of each verb, and of each Renderer, against
+// the golden files in testdata/render. Run with -update to rewrite them.
+func TestRender(t *testing.T) {
+	done := setupFrame()
+	defer done()
+
+	ctx := ctxlog.WithAttrs(context.Background(), slog.String("module", "db"))
+	cases := []struct {
+		name string
+		err  func() error
+	}{
+		{"wrapped", func() error {
+			return E(Op("one"), "error", E(Op("two"), "error building foo", E(Op("three"), "error building bar", errors.New("concrete"))))
+		}},
+		{"ops", func() error {
+			return E(Op("a"), E(Op("b"), E(Op("c"), "msg")))
+		}},
+		{"stack", myFunc1},
+		{"attrs", func() error {
+			return E(Op("load"), NotFound, Temporary, "missing", slog.String("user", "bob"),
+				E(Op("read"), slog.Group("file", slog.String("name", "a.txt"), slog.Int("size", 3)), io.EOF))
+		}},
+		{"prefix", func() error {
+			return E(Op("query"), ContextError(ctx, io.EOF))
+		}},
+		{"empty", func() error { return E() }},
+	}
+	renderers := []struct {
+		name string
+		r    Renderer
+	}{
+		{"SingleLine", SingleLine},
+		{"Compact", Compact},
+		{"Tree", Tree},
+		{"Bracketed", Bracketed},
+		{"BracketedChain", BracketedChain},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.err()
+			var b strings.Builder
+			for _, verb := range []string{"%s", "%q", "%v", "%+v"} {
+				fmt.Fprintf(&b, "-- %s --\n"+verb+"\n", verb, err)
+			}
+			for _, r := range renderers {
+				fmt.Fprintf(&b, "-- %s --\n", r.name)
+				r.r(&b, err.(*Error))
+				b.WriteString("\n")
+			}
+			got := b.String()
+
+			golden := filepath.Join("testdata", "render", tc.name+".golden")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("ReadFile() got error: %s", err)
+			}
+			if diff := cmp.Diff(string(want), got); diff != "" {
+				t.Errorf("Render got diff -want/+got: %s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderingVerbs(t *testing.T) {
+	defer func(orig Verbs) { Rendering = orig }(Rendering)
+	Rendering = Verbs{S: Compact, V: Bracketed}
+
+	err := E(Op("a"), "outer", E(Op("b"), "inner"))
+	if got, want := err.Error(), "a: b: inner"; got != want {
+		t.Errorf("Error() got %q wanted %q", got, want)
+	}
+	if got, want := fmt.Sprintf("%v", err), "[a] : outer[b] : inner"; got != want {
+		t.Errorf("%%v got %q wanted %q", got, want)
+	}
+	// A nil Renderer uses the default.
+	if got, want := fmt.Sprintf("%+v", err), "a: outer\n  b: inner"; !strings.HasPrefix(got, want) {
+		t.Errorf("%%+v got %q wanted prefix %q", got, want)
+	}
+}
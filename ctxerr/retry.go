@@ -0,0 +1,182 @@
+package ctxerr
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type temporary bool
+
+// Temporary is passed to E() to mark the error as safe to retry.
+const Temporary = temporary(true)
+
+// IsTemporary reports whether any error in the chain of err is marked as
+// Temporary, or implements a Temporary() bool method that returns true.
+// Errors that wrap several errors, such as a Multi, are searched as well.
+func IsTemporary(err error) bool {
+	for curr := range tree(err) {
+		switch e := curr.(type) {
+		case *Error:
+			if e.Temporary {
+				return true
+			}
+		case interface{ Temporary() bool }:
+			if e.Temporary() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Clock provides the time to Retry. It is replaced in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RetryPolicy controls Retry. The zero value makes 3 attempts with an
+// exponential backoff starting at 100ms, retrying errors marked Temporary.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of calls, 3 if zero. A negative value
+	// retries until the context expires.
+	MaxAttempts int
+	// InitialBackoff is the wait after the first attempt, 100ms if zero.
+	InitialBackoff time.Duration
+	// MaxBackoff limits the wait between attempts, unlimited if zero.
+	MaxBackoff time.Duration
+	// Multiplier grows the wait after each attempt, 2 if zero.
+	Multiplier float64
+	// Jitter randomizes each wait by up to this fraction in either direction.
+	Jitter float64
+	// Retryable decides if an error is retried, IsTemporary if nil.
+	Retryable func(error) bool
+	// Clock provides the time, the real clock if nil.
+	Clock Clock
+	// Rand returns values in [0, 1) used for jitter, math/rand if nil.
+	Rand func() float64
+}
+
+func (p *RetryPolicy) defaults() {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = 3
+	}
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = 2
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsTemporary
+	}
+	if p.Clock == nil {
+		p.Clock = realClock{}
+	}
+	if p.Rand == nil {
+		p.Rand = rand.Float64
+	}
+}
+
+// backoff returns the wait after the specified attempt, starting at 1.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+		if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*p.Rand() - 1)
+	}
+	return time.Duration(d)
+}
+
+// Attempts is the history of errors returned by each attempt of Retry. Each
+// error is wrapped in an *Error with an "attempt" attribute.
+type Attempts []error
+
+func (a Attempts) Error() string {
+	var b strings.Builder
+	for i, err := range a {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString("attempt " + strconv.Itoa(i+1) + ": " + err.Error())
+	}
+	return b.String()
+}
+
+func (a Attempts) Unwrap() []error { return a }
+
+// stopped is the cause of the error returned by Retry when the context stops
+// it, keeping both the context error and the Attempts history in the chain.
+type stopped struct {
+	ctxErr  error
+	history Attempts
+}
+
+func (s stopped) Error() string { return s.ctxErr.Error() + ": " + s.history.Error() }
+
+func (s stopped) Unwrap() []error { return []error{s.ctxErr, s.history} }
+
+// Retry calls fn until it succeeds, returns an error that is not retryable,
+// the attempts are exhausted or the context expires. Between attempts it
+// waits with an exponential backoff, and it does not wait past the deadline
+// of the context.
+//
+// When Retry gives up it returns an *Error with Op "ctxerr.Retry" and the Kind
+// of the last error, whose cause is the Attempts history. If the context
+// stops it, the Kind is Canceled or Timeout and the cause also wraps the
+// context error, so errors.Is(err, context.DeadlineExceeded) is true.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(context.Context) error) error {
+	const op = Op("ctxerr.Retry")
+	policy.defaults()
+
+	var history Attempts
+	giveUp := func(reason string, ctxErr error) error {
+		attempts := slog.Int("attempts", len(history))
+		if ctxErr != nil {
+			return E(op, NoStack, KindOf(ctxErr), reason, attempts, stopped{ctxErr, history})
+		}
+		last := history[len(history)-1]
+		return E(op, NoStack, KindOf(last), reason, attempts, history)
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		history = append(history, E(op, NoStack, slog.Int("attempt", attempt), err))
+
+		switch {
+		case !policy.Retryable(err):
+			return giveUp("error is not retryable", nil)
+		case policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts:
+			return giveUp("too many attempts", nil)
+		}
+
+		wait := policy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && policy.Clock.Now().Add(wait).After(deadline) {
+			return giveUp("deadline exceeded before next attempt", context.DeadlineExceeded)
+		}
+		select {
+		case <-ctx.Done():
+			return giveUp("stopped by context", ctx.Err())
+		case <-policy.Clock.After(wait):
+		}
+	}
+}
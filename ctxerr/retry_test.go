@@ -0,0 +1,140 @@
+package ctxerr
+
+import (
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeClock records each wait and advances its time immediately.
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// blockingClock never fires.
+type blockingClock struct{}
+
+func (blockingClock) Now() time.Time                       { return time.Now() }
+func (blockingClock) After(time.Duration) <-chan time.Time { return nil }
+
+// failN returns a func that fails with err for the first n calls.
+func failN(n int, err error) (func(context.Context) error, *int) {
+	var calls int
+	return func(context.Context) error {
+		calls++
+		if calls <= n {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+func TestRetry(t *testing.T) {
+	temp := E(Op("call"), Unavailable, Temporary, "try again")
+
+	t.Run("Success", func(t *testing.T) {
+		clock := &fakeClock{}
+		fn, calls := failN(2, temp)
+		err := Retry(context.Background(), RetryPolicy{Clock: clock}, fn)
+		if err != nil {
+			t.Errorf("Retry() got %v wanted nil", err)
+		}
+		if *calls != 3 {
+			t.Errorf("Retry() got %d calls wanted 3", *calls)
+		}
+		want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}
+		if diff := cmp.Diff(want, clock.waits); diff != "" {
+			t.Errorf("Retry() waits got diff -want/+got: %s", diff)
+		}
+	})
+	t.Run("MaxAttempts", func(t *testing.T) {
+		clock := &fakeClock{}
+		fn, calls := failN(10, temp)
+		err := Retry(context.Background(), RetryPolicy{Clock: clock, MaxAttempts: 4, MaxBackoff: 300 * time.Millisecond}, fn)
+		if *calls != 4 {
+			t.Errorf("Retry() got %d calls wanted 4", *calls)
+		}
+		want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+		if diff := cmp.Diff(want, clock.waits); diff != "" {
+			t.Errorf("Retry() waits got diff -want/+got: %s", diff)
+		}
+		if KindOf(err) != Unavailable {
+			t.Errorf("KindOf() got %v wanted %v", KindOf(err), Unavailable)
+		}
+		var history Attempts
+		if !errors.As(err, &history) || len(history) != 4 {
+			t.Fatalf("Retry() got history %v wanted 4 attempts", history)
+		}
+		if v, ok := LookupAttr(history[3], "attempt", Outermost); !ok || v.Int64() != 4 {
+			t.Errorf("Retry() got attempt attr %v wanted 4", v)
+		}
+		if v, ok := LookupAttr(err, "attempts", Outermost); !ok || v.Int64() != 4 {
+			t.Errorf("Retry() got attempts attr %v wanted 4", v)
+		}
+	})
+	t.Run("NotRetryable", func(t *testing.T) {
+		fn, calls := failN(10, io.EOF)
+		err := Retry(context.Background(), RetryPolicy{Clock: &fakeClock{}}, fn)
+		if *calls != 1 {
+			t.Errorf("Retry() got %d calls wanted 1", *calls)
+		}
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("errors.Is(err, io.EOF) got false wanted true")
+		}
+	})
+	t.Run("Deadline", func(t *testing.T) {
+		clock := &fakeClock{now: time.Now()}
+		ctx, cancel := context.WithDeadline(context.Background(), clock.now.Add(250*time.Millisecond))
+		defer cancel()
+		fn, calls := failN(10, temp)
+		err := Retry(ctx, RetryPolicy{Clock: clock, MaxAttempts: -1}, fn)
+		// waits 100ms and 200ms would pass the deadline.
+		if *calls != 2 {
+			t.Errorf("Retry() got %d calls wanted 2", *calls)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) || KindOf(err) != Timeout {
+			t.Errorf("Retry() got %v, kind %v wanted context.DeadlineExceeded", err, KindOf(err))
+		}
+		var history Attempts
+		if !errors.As(err, &history) || len(history) != 2 {
+			t.Errorf("Retry() got history %v wanted 2 attempts", history)
+		}
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		// blocks the wait, so Retry only returns when canceled.
+		clock := blockingClock{}
+		fn := func(context.Context) error {
+			cancel()
+			return temp
+		}
+		err := Retry(ctx, RetryPolicy{Clock: clock, MaxAttempts: -1}, fn)
+		if !errors.Is(err, context.Canceled) || KindOf(err) != Canceled {
+			t.Errorf("Retry() got %v, kind %v wanted context.Canceled", err, KindOf(err))
+		}
+		if !errors.Is(err, Unavailable) {
+			t.Errorf("errors.Is(err, Unavailable) got false wanted true")
+		}
+	})
+	t.Run("Jitter", func(t *testing.T) {
+		p := RetryPolicy{Jitter: 0.5, Rand: func() float64 { return 1 }}
+		p.defaults()
+		if got, want := p.backoff(1), 150*time.Millisecond; got != want {
+			t.Errorf("backoff() got %v wanted %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package ctxerr
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Sentinel is a package-level error with a stable code, declared with Define:
+//
+//	var ErrNotFound = ctxerr.Define("storage.not_found", ctxerr.NotFound)
+//
+// The text of a Sentinel is its code, and it is restored by FromWire,
+// UnmarshalJSON and ParseError, so errors.Is(err, ErrNotFound) keeps working
+// after an error crosses a process boundary.
+type Sentinel struct {
+	code string
+	kind Kind
+}
+
+// Code returns the code the Sentinel was defined with.
+func (s *Sentinel) Code() string { return s.code }
+
+// Kind returns the Kind the Sentinel was defined with.
+func (s *Sentinel) Kind() Kind { return s.kind }
+
+func (s *Sentinel) Error() string { return s.code }
+
+// Is reports whether target is the Kind of the Sentinel, so that
+// errors.Is(ErrNotFound, NotFound) is true.
+func (s *Sentinel) Is(target error) bool {
+	k, ok := target.(Kind)
+	return ok && k != Other && s.kind == k
+}
+
+var registry struct {
+	sync.Mutex
+	codes map[string]*Sentinel
+}
+
+// Define returns a new Sentinel and registers it by its code. It is meant to
+// be called when initializing package variables, and panics if the code is
+// empty, contains spaces or is already defined.
+func Define(code string, kind Kind) *Sentinel {
+	if code == "" || strings.ContainsAny(code, " \t\n") {
+		panic(fmt.Sprintf("ctxerr.Define: invalid code %q", code))
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.codes[code]; ok {
+		panic(fmt.Sprintf("ctxerr.Define: duplicate code %q", code))
+	}
+	if registry.codes == nil {
+		registry.codes = make(map[string]*Sentinel)
+	}
+	s := &Sentinel{code: code, kind: kind}
+	registry.codes[code] = s
+	return s
+}
+
+// Lookup returns the Sentinel defined with the code.
+func Lookup(code string) (*Sentinel, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+	s, ok := registry.codes[code]
+	return s, ok
+}
+
+// Sentinels returns every defined Sentinel ordered by code, such as to
+// generate documentation of the errors a service returns.
+func Sentinels() []*Sentinel {
+	registry.Lock()
+	defer registry.Unlock()
+	out := make([]*Sentinel, 0, len(registry.codes))
+	for _, s := range registry.codes {
+		out = append(out, s)
+	}
+	slices.SortFunc(out, func(a, b *Sentinel) int { return cmp.Compare(a.code, b.code) })
+	return out
+}
+
+// ParseError rebuilds an error from its text, such as the result of Error()
+// read from a log. If the text is, or ends with ": " followed by, the code of
+// a Sentinel, the result wraps the Sentinel and has the same text. Otherwise
+// it is the registered sentinel with the text (see RegisterSentinel) or a new
+// error with the text.
+func ParseError(text string) error {
+	if s, ok := Lookup(text); ok {
+		return s
+	}
+	if i := strings.LastIndex(text, ": "); i >= 0 {
+		if s, ok := Lookup(text[i+len(": "):]); ok {
+			return &Error{Msg: text[:i], Err: s}
+		}
+	}
+	return sentinelFor(text)
+}
@@ -0,0 +1,116 @@
+package ctxerr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var (
+	errStorageNotFound = Define("storage.not_found", NotFound)
+	errFirst           = Define("a.first", Invalid)
+)
+
+func TestSentinel(t *testing.T) {
+	err := E(Op("load"), "row 3", errStorageNotFound)
+	if got, want := err.Error(), "load: row 3: storage.not_found"; got != want {
+		t.Errorf("Error() got %q wanted %q", got, want)
+	}
+	if KindOf(err) != NotFound || !errors.Is(err, NotFound) {
+		t.Errorf("KindOf() got %v wanted %v", KindOf(err), NotFound)
+	}
+	if s, ok := Lookup("storage.not_found"); !ok || s != errStorageNotFound {
+		t.Errorf("Lookup() got %v, %t wanted errStorageNotFound", s, ok)
+	}
+	if _, ok := Lookup("storage.missing"); ok {
+		t.Errorf("Lookup(undefined) got true wanted false")
+	}
+}
+
+func TestSentinelRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{"Direct", E(Op("load"), errStorageNotFound)},
+		{"Wrapped", E(Op("load"), fmt.Errorf("reading: %w", errStorageNotFound))},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.err)
+			if err != nil {
+				t.Fatalf("Marshal() got error: %s", err)
+			}
+			var got Error
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("Unmarshal() got error: %s", err)
+			}
+			if !errors.Is(&got, errStorageNotFound) {
+				t.Errorf("errors.Is(errStorageNotFound) got false wanted true, %s", b)
+			}
+			if got.Error() != tc.err.Error() {
+				t.Errorf("Error() got %q wanted %q", got.Error(), tc.err.Error())
+			}
+		})
+	}
+}
+
+func TestUnmarshalCodeOnly(t *testing.T) {
+	var got Error
+	if err := json.Unmarshal([]byte(`{"code":"storage.not_found"}`), &got); err != nil {
+		t.Fatalf("Unmarshal() got error: %s", err)
+	}
+	if got.Err != errStorageNotFound {
+		t.Errorf("Err got %v wanted errStorageNotFound", got.Err)
+	}
+	if KindOf(&got) != NotFound {
+		t.Errorf("KindOf() got %v wanted %v", KindOf(&got), NotFound)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	cases := []struct {
+		text   string
+		wantIs bool
+	}{
+		{"storage.not_found", true},
+		{"load: row 3: storage.not_found", true},
+		{"load: storage.not_found: more", false},
+		{"something else", false},
+	}
+	for _, tc := range cases {
+		err := ParseError(tc.text)
+		if got := errors.Is(err, errStorageNotFound); got != tc.wantIs {
+			t.Errorf("ParseError(%q) errors.Is got %t wanted %t", tc.text, got, tc.wantIs)
+		}
+		if err.Error() != tc.text {
+			t.Errorf("ParseError(%q) got text %q", tc.text, err.Error())
+		}
+	}
+}
+
+func TestDefinePanics(t *testing.T) {
+	for _, code := range []string{"storage.not_found", "", "has space"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Define(%q) did not panic", code)
+				}
+			}()
+			Define(code, Internal)
+		}()
+	}
+}
+
+func TestSentinels(t *testing.T) {
+	list := Sentinels()
+	for i := 1; i < len(list); i++ {
+		if list[i-1].Code() >= list[i].Code() {
+			t.Errorf("Sentinels() not sorted: %q before %q", list[i-1].Code(), list[i].Code())
+		}
+	}
+	if len(list) < 2 || list[0] != errFirst || list[0].Kind() != Invalid {
+		t.Errorf("Sentinels() got %v", list)
+	}
+}
@@ -3,48 +3,157 @@ package ctxerr
 import (
 	"errors"
 	"fmt"
+	"github.com/nveeser/srvsrv/ctxerr/internal/hooks"
+	"path"
 	"runtime"
+	"runtime/debug"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type stack struct {
 	callers []uintptr
+	// frames holds an already symbolized stack, such as one decoded from
+	// another process, innermost first. Used when callers is empty.
+	frames []frame
+}
+
+func (s *stack) hasStack() bool {
+	return len(s.callers) > 0 || len(s.frames) > 0
+}
+
+// allFrames returns every frame of the stack, innermost first.
+func (s *stack) allFrames() []frame {
+	if len(s.callers) == 0 {
+		return s.frames
+	}
+	return resolveFrames(s.callers)
+}
+
+// Frame is a symbolized frame of the stack captured by an Error.
+type Frame struct {
+	Function string // Fully qualified function name
+	File     string
+	Line     int
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s:%d %s", f.File, f.Line, f.Function)
+}
+
+// StackTrace returns the stack captured by the innermost *Error in the chain
+// that has one, with the frame that created the error first. It returns nil if
+// no stack was captured.
+func (e *Error) StackTrace() []Frame {
+	stacked := stackedError(e)
+	if stacked == nil {
+		return nil
+	}
+	frames := renderFrames(stacked.stack.allFrames())
+	out := make([]Frame, len(frames))
+	for i, f := range frames {
+		out[i] = Frame{Function: f.funcName, File: f.file, Line: f.line}
+	}
+	return out
+}
+
+// stackedError returns the innermost *Error in the chain with a stack.
+func stackedError(err error) *Error {
+	var stacked *Error
+	for curr := err; curr != nil; curr = errors.Unwrap(curr) {
+		if ee, ok := curr.(*Error); ok && ee.hasStack() {
+			stacked = ee
+		}
+	}
+	return stacked
 }
 
 // populateStack will update the callers if there is no existing
-// Error value found by unwrapping this error with errors.As().
-func (e *Error) populateStack() {
+// Error value found by unwrapping this error with errors.As(), and
+// the policy selects this error.
+func (e *Error) populateStack(p CapturePolicy) {
 	var e2 *Error
 	// only if there is no *Error value in the cause chain.
-	if !errors.As(e.Err, &e2) {
-		e.stack = callers(5)
+	if !errors.As(e.Err, &e2) && p.captureStack(e.Kind) {
+		e.stack = callersDepth(5, p.maxDepth())
 	}
 }
 
+// StackOptions controls how stacks are rendered by Format and in log values.
+type StackOptions struct {
+	// TrimGOROOT removes the GOROOT prefix from file names in the standard
+	// library.
+	TrimGOROOT bool
+	// TrimModules removes the module path from function names, and the module
+	// directory from file names, for functions in the listed modules. The
+	// special value "main" is the main module of the binary.
+	TrimModules []string
+	// ElidePackage removes the part of each function name that is shared with
+	// the previous frame, such as the package path.
+	ElidePackage bool
+	// Depth limits the number of frames written, keeping those closest to
+	// where the error was created. Zero means no limit.
+	Depth int
+}
+
+var stackFormat atomic.Pointer[StackOptions]
+
+// SetStackFormat sets the StackOptions used when rendering stacks. It is safe
+// to call while errors are being rendered.
+func SetStackFormat(o StackOptions) {
+	o.TrimModules = slices.Clone(o.TrimModules)
+	stackFormat.Store(&o)
+}
+
+func currentStackFormat() StackOptions {
+	if o := stackFormat.Load(); o != nil {
+		return *o
+	}
+	return StackOptions{}
+}
+
 type stackFn func(file string, line int, fname string)
 
+// walkStack calls f with each frame of the stack from the outermost call
+// inward, skipping the frames shared with the stack of the caller.
 func (e *Error) walkStack(skip int, f stackFn) {
-	walkerStack := callers(skip)
-	var prev string // the name of the last-seen function
-	var diff bool   // true after the two stacks diverge
-	for i := 0; i < len(e.stack.callers); i++ {
-		thisFrame := callerFrame(e.stack.callers, i)
-		name := thisFrame.funcName
-		if !diff && i < len(walkerStack.callers) {
-			cFrame := callerFrame(walkerStack.callers, i)
-			if name == cFrame.funcName {
-				// both stacks share this PC, skip it.
-				continue
-			}
-			diff = true
+	opts := currentStackFormat()
+	frames := slices.Clone(renderFrames(e.stack.allFrames()))
+	slices.Reverse(frames)
+	if len(e.stack.callers) > 0 {
+		walker := renderFrames(resolveFrames(callers(skip).callers))
+		slices.Reverse(walker)
+		// both stacks share these frames, skip them.
+		n := 0
+		for n < len(frames) && n < len(walker) && frames[n].funcName == walker[n].funcName {
+			n++
 		}
-		if name == prev {
+		frames = frames[n:]
+	}
+
+	var out []frame
+	var prev string // the name of the last-seen function
+	for _, fr := range frames {
+		if fr.funcName == prev {
 			continue
 		}
-		// TODO - consider re-enabling trimming to keep file paths cleaner
-		// name, ok := trimPrev(prev, name)
-		f(thisFrame.file, thisFrame.line, name)
+		name := fr.funcName
+		fr = opts.trim(fr)
+		if opts.ElidePackage && prev != "" {
+			if trimmed, ok := trimPrev(prev, name); ok {
+				fr.funcName = trimmed
+			}
+		}
 		prev = name
+		out = append(out, fr)
+	}
+	if opts.Depth > 0 && len(out) > opts.Depth {
+		out = out[len(out)-opts.Depth:]
+	}
+	for _, fr := range out {
+		f(fr.file, fr.line, fr.funcName)
 	}
 }
 
@@ -65,6 +174,54 @@ func trimPrev(prev, next string) (string, bool) {
 	return next[trim:], trim > 0
 }
 
+// trim applies the path trimming options to the frame.
+func (o StackOptions) trim(f frame) frame {
+	if o.TrimGOROOT {
+		if root := goroot(); root != "" {
+			f.file = strings.TrimPrefix(f.file, root)
+		}
+	}
+	for _, mod := range o.TrimModules {
+		if mod == "main" {
+			mod = mainModule()
+		}
+		name, ok := strings.CutPrefix(f.funcName, mod+"/")
+		if mod == "" || !ok {
+			continue
+		}
+		f.funcName = name
+		// Trim the module directory from the file name, leaving the
+		// package path relative to the module, e.g. "ctxerr/errors.go".
+		pkg := name
+		slash := strings.LastIndex(name, "/") + 1
+		if dot := strings.Index(name[slash:], "."); dot >= 0 {
+			pkg = name[:slash+dot]
+		}
+		if strings.HasSuffix(path.Dir(f.file), "/"+pkg) {
+			f.file = pkg + "/" + path.Base(f.file)
+		}
+		break
+	}
+	return f
+}
+
+var goroot = sync.OnceValue(func() string {
+	// Find GOROOT from the location of the runtime package.
+	for _, f := range resolveFrames(callers(0).callers) {
+		if i := strings.Index(f.file, "/src/runtime/"); i >= 0 {
+			return f.file[:i+len("/src/")]
+		}
+	}
+	return ""
+})
+
+var mainModule = sync.OnceValue(func() string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		return bi.Main.Path
+	}
+	return ""
+})
+
 type frame struct {
 	file     string
 	line     int
@@ -75,27 +232,60 @@ func (f *frame) String() string {
 	return fmt.Sprintf("[%s:%d] %s", f.file, f.line, f.funcName)
 }
 
-// frame returns the nth frame, with the frame at top of stack being 0.
-var callerFrame = func(callers []uintptr, n int) *frame {
-	frames := runtime.CallersFrames(callers)
-	var f runtime.Frame
-	for i := len(callers) - 1; i >= n; i-- {
-		var ok bool
-		f, ok = frames.Next()
-		if !ok {
-			break // Should never happen, and this is just debugging.
+var frameCache sync.Map // map[uintptr][]frame
+
+// resolveFrames symbolizes the callers, innermost first. runtime.Callers
+// already reports each inlined call as a PC of its own, so a PC usually
+// resolves to a single frame. Frames are cached by PC.
+func resolveFrames(callers []uintptr) []frame {
+	out := make([]frame, 0, len(callers))
+	for _, pc := range callers {
+		if cached, ok := frameCache.Load(pc); ok {
+			out = append(out, cached.([]frame)...)
+			continue
+		}
+		var resolved []frame
+		frames := runtime.CallersFrames([]uintptr{pc})
+		for {
+			f, more := frames.Next()
+			resolved = append(resolved, frame{file: f.File, line: f.Line, funcName: f.Function})
+			if !more {
+				break
+			}
 		}
+		frameCache.Store(pc, resolved)
+		out = append(out, resolved...)
+	}
+	return out
+}
+
+// renderFrames returns the frames to render, rewritten by hooks.Frames if
+// set. Only frames that are written out go through the hook, frames used to
+// find GOROOT or the caller's Op are not.
+func renderFrames(frames []frame) []frame {
+	hook := hooks.Frames.Load()
+	if hook == nil {
+		return frames
 	}
-	return &frame{
-		file:     f.File,
-		line:     f.Line,
-		funcName: f.Func.Name(),
+	in := make([]hooks.Frame, len(frames))
+	for i, f := range frames {
+		in[i] = hooks.Frame{File: f.file, Line: f.line, Func: f.funcName}
 	}
+	var out []frame
+	for _, f := range (*hook)(in) {
+		out = append(out, frame{file: f.File, line: f.Line, funcName: f.Func})
+	}
+	return out
 }
 
 // callers is a wrapper for runtime.Callers that allocates a slice.
 func callers(skip int) stack {
-	var stk [64]uintptr
-	n := runtime.Callers(skip, stk[:])
-	return stack{stk[:n]}
+	return callersDepth(skip+1, defaultMaxDepth)
+}
+
+// callersDepth captures at most depth PCs.
+func callersDepth(skip, depth int) stack {
+	stk := make([]uintptr, depth)
+	n := runtime.Callers(skip, stk)
+	return stack{callers: stk[:n:n]}
 }
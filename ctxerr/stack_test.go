@@ -0,0 +1,141 @@
+package ctxerr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nveeser/srvsrv/ctxerr/internal/hooks"
+	"strings"
+	"testing"
+)
+
+func TestStackTrace(t *testing.T) {
+	err := E(Op("outer"), myFunc1())
+	st := err.(*Error).StackTrace()
+	var got []string
+	for _, f := range st[:3] {
+		got = append(got, f.Function)
+	}
+	want := []string{
+		"github.com/nveeser/srvsrv/ctxerr.myFunc3",
+		"github.com/nveeser/srvsrv/ctxerr.T.myFunc2",
+		"github.com/nveeser/srvsrv/ctxerr.myFunc1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("StackTrace() got\n%s\nwanted\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !strings.HasSuffix(st[0].File, "ctxerr/errors_test.go") {
+		t.Errorf("StackTrace() got file %q", st[0].File)
+	}
+
+	if st := (&Error{Msg: "no stack"}).StackTrace(); st != nil {
+		t.Errorf("StackTrace() got %v wanted nil", st)
+	}
+}
+
+func TestStackOptionsTrim(t *testing.T) {
+	root := goroot()
+	if root == "" {
+		t.Skip("GOROOT not found")
+	}
+	cases := []struct {
+		name string
+		opts StackOptions
+		in   frame
+		want frame
+	}{
+		{
+			name: "GOROOT",
+			opts: StackOptions{TrimGOROOT: true},
+			in:   frame{file: root + "testing/testing.go", funcName: "testing.tRunner"},
+			want: frame{file: "testing/testing.go", funcName: "testing.tRunner"},
+		},
+		{
+			name: "Module",
+			opts: StackOptions{TrimModules: []string{"example.com/mod"}},
+			in:   frame{file: "/src/mod/sub/pkg/f.go", funcName: "example.com/mod/sub/pkg.(*T).F"},
+			want: frame{file: "sub/pkg/f.go", funcName: "sub/pkg.(*T).F"},
+		},
+		{
+			name: "OtherModule",
+			opts: StackOptions{TrimModules: []string{"example.com/mod"}},
+			in:   frame{file: "/src/other/f.go", funcName: "example.com/modother.F"},
+			want: frame{file: "/src/other/f.go", funcName: "example.com/modother.F"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.opts.trim(tc.in); got != tc.want {
+				t.Errorf("trim() got %v wanted %v", &got, &tc.want)
+			}
+		})
+	}
+}
+
+func BenchmarkE(b *testing.B) {
+	cause := errors.New("cause")
+	b.Run("Stack", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = E(Op("op"), "msg", cause)
+		}
+	})
+	b.Run("NoStack", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = E(Op("op"), "msg", cause, NoStack)
+		}
+	})
+	b.Run("Lite", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = E(Op("op"), "msg", cause, Lite)
+		}
+	})
+	b.Run("Wrap", func(b *testing.B) {
+		inner := E(Op("inner"), cause)
+		for i := 0; i < b.N; i++ {
+			_ = E(Op("op"), "msg", inner)
+		}
+	})
+}
+
+func BenchmarkFormat(b *testing.B) {
+	err := E(Op("outer"), myFunc1())
+	b.Run("Error", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = err.Error()
+		}
+	})
+	b.Run("Stack", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = fmt.Sprintf("%+v", err)
+		}
+	})
+	b.Run("StackTrace", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = err.(*Error).StackTrace()
+		}
+	})
+}
+
+func TestFramesHook(t *testing.T) {
+	// The hook adds a frame and hides the files of the others.
+	hook := func(frames []hooks.Frame) []hooks.Frame {
+		out := []hooks.Frame{{File: "extra.go", Func: "extra"}}
+		for _, f := range frames {
+			out = append(out, hooks.Frame{File: "hidden.go", Func: f.Func})
+		}
+		return out
+	}
+	prev := hooks.Frames.Swap(&hook)
+	defer hooks.Frames.Store(prev)
+
+	e := E(Op("op"), "msg").(*Error)
+	st := e.StackTrace()
+	if len(st) != len(e.stack.allFrames())+1 || st[0].Function != "extra" {
+		t.Errorf("StackTrace() got %v wanted the extra frame first", st)
+	}
+	if root := goroot(); root == "" || !strings.HasSuffix(root, "/src/") {
+		t.Errorf("goroot() got %q wanted the GOROOT source directory", root)
+	}
+	if got := fmt.Sprintf("%+v", e); !strings.Contains(got, "extra.go") {
+		t.Errorf("Format got %s wanted the extra frame", got)
+	}
+}
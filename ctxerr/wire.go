@@ -0,0 +1,340 @@
+package ctxerr
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WireError is the serialized form of an error chain. It is a flat structure
+// of strings, integers and repeated fields so it maps directly onto a protobuf
+// message, and is the JSON encoding used by MarshalJSON. Kind holds a stable
+// code for the Kind, such as "not_found".
+type WireError struct {
+	Op        string      `json:"op,omitempty"`
+	Kind      string      `json:"kind,omitempty"`
+	Msg       string      `json:"msg,omitempty"`
+	Temporary bool        `json:"temporary,omitempty"`
+	Attrs     []WireAttr  `json:"attrs,omitempty"`
+	Prefix    []WireAttr  `json:"prefix,omitempty"`
+	Stack     []WireFrame `json:"stack,omitempty"`
+	Cause     *WireError  `json:"cause,omitempty"`
+	// Causes holds the errors collected by a Multi, or joined by an error
+	// with an Unwrap() []error method.
+	Causes []*WireError `json:"causes,omitempty"`
+	// Multi marks a Multi, which has only an Op, Attrs and Causes.
+	Multi bool `json:"multi,omitempty"`
+	// Count is the number of times an error collected by a Multi was added,
+	// when more than once.
+	Count int `json:"count,omitempty"`
+	// Error holds the text of an error that is not an *Error. When set, the
+	// other fields are empty, except Code, and Cause or Causes for the errors
+	// it wraps.
+	Error string `json:"error,omitempty"`
+	// Code is the code of a Sentinel, see Define.
+	Code string `json:"code,omitempty"`
+}
+
+// WireAttr is the serialized form of a slog.Attr. Kind is the name of the
+// slog.Kind of the value, which is used to restore the type of the value.
+// Groups hold their members in Group instead of Value.
+type WireAttr struct {
+	Key   string     `json:"key"`
+	Kind  string     `json:"kind,omitempty"`
+	Value string     `json:"value,omitempty"`
+	Group []WireAttr `json:"group,omitempty"`
+}
+
+// WireFrame is the serialized form of a symbolized stack frame.
+type WireFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// WireOptions controls the conversion of an error to a WireError.
+type WireOptions struct {
+	// Stack includes the symbolized stack of each *Error.
+	Stack bool
+}
+
+// ToWire converts the error chain of err to a WireError. It returns nil if
+// err is nil. Errors that are neither an *Error nor a Multi keep only their
+// text, but the errors they wrap are converted too, so an *Error wrapped with
+// fmt.Errorf keeps its Op, Kind and attributes.
+func (o WireOptions) ToWire(err error) *WireError {
+	if err == nil {
+		return nil
+	}
+	switch e := err.(type) {
+	case *Sentinel:
+		return &WireError{Error: e.Error(), Code: e.code}
+	case *Error:
+		return o.errorToWire(e)
+	case *Multi:
+		w := &WireError{
+			Op:    string(e.Op),
+			Attrs: toWireAttrs(renderAttrs(e.Attrs)),
+			Multi: true,
+		}
+		for _, entry := range e.entries() {
+			cause := o.ToWire(entry.err)
+			if entry.count > 1 {
+				cause.Count = entry.count
+			}
+			w.Causes = append(w.Causes, cause)
+		}
+		return w
+	case interface{ Unwrap() error }:
+		return &WireError{Error: err.Error(), Cause: o.ToWire(e.Unwrap())}
+	case interface{ Unwrap() []error }:
+		w := &WireError{Error: err.Error()}
+		for _, cause := range e.Unwrap() {
+			w.Causes = append(w.Causes, o.ToWire(cause))
+		}
+		return w
+	}
+	return &WireError{Error: err.Error()}
+}
+
+func (o WireOptions) errorToWire(e *Error) *WireError {
+	w := &WireError{
+		Op:        string(e.Op),
+		Msg:       e.Msg,
+		Attrs:     toWireAttrs(renderAttrs(e.Attrs)),
+		Prefix:    toWireAttrs(renderAttrs(e.Prefix)),
+		Temporary: e.Temporary,
+		Cause:     o.ToWire(e.Err),
+	}
+	if e.Kind != Other {
+		w.Kind = e.Kind.wireCode()
+	}
+	if o.Stack {
+		for _, f := range renderFrames(e.stack.allFrames()) {
+			w.Stack = append(w.Stack, WireFrame{Func: f.funcName, File: f.file, Line: f.line})
+		}
+	}
+	return w
+}
+
+// ToWire converts the error chain of err to a WireError without stacks.
+func ToWire(err error) *WireError { return WireOptions{}.ToWire(err) }
+
+// FromWire rebuilds the error chain encoded in w. Each level that was an
+// *Error or a Multi is restored as one, and each Sentinel by its code. Other
+// errors that wrapped errors are restored as an error with the same text that
+// wraps them, and the rest from their text by ParseError.
+func FromWire(w *WireError) error {
+	if w == nil {
+		return nil
+	}
+	if w.Code != "" {
+		if s, ok := Lookup(w.Code); ok {
+			return s
+		}
+	}
+	if w.Multi {
+		m := &Multi{Op: Op(w.Op), Attrs: fromWireAttrs(w.Attrs)}
+		for _, c := range w.Causes {
+			if err := FromWire(c); err != nil {
+				m.errs = append(m.errs, multiEntry{err: err, count: max(c.Count, 1)})
+			}
+		}
+		m.redactCaptured()
+		return m
+	}
+	if w.Error != "" {
+		switch {
+		case w.Cause != nil:
+			return &wrapError{msg: w.Error, err: FromWire(w.Cause)}
+		case len(w.Causes) > 0:
+			j := &joinError{msg: w.Error}
+			for _, c := range w.Causes {
+				j.errs = append(j.errs, FromWire(c))
+			}
+			return j
+		}
+		return ParseError(w.Error)
+	}
+	e := &Error{
+		Op:        Op(w.Op),
+		Kind:      parseKind(w.Kind),
+		Msg:       w.Msg,
+		Attrs:     fromWireAttrs(w.Attrs),
+		Prefix:    fromWireAttrs(w.Prefix),
+		Temporary: w.Temporary,
+		Err:       FromWire(w.Cause),
+	}
+	for _, f := range w.Stack {
+		e.stack.frames = append(e.stack.frames, frame{file: f.File, line: f.Line, funcName: f.Func})
+	}
+	// A peer may not redact, treat the decoded error as newly captured.
+	e.redactCaptured()
+	return e
+}
+
+// MarshalJSON encodes the error chain as a WireError. Stacks are not
+// included, use WireOptions to include them.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ToWire(e))
+}
+
+// UnmarshalJSON decodes an error chain encoded by MarshalJSON.
+func (e *Error) UnmarshalJSON(data []byte) error {
+	var w WireError
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	switch err := FromWire(&w).(type) {
+	case *Error:
+		*e = *err
+	default:
+		// The top of the chain was not an *Error, such as a Sentinel or
+		// the text of another error, keep it as the cause.
+		*e = Error{Err: err}
+	}
+	return nil
+}
+
+var sentinels sync.Map // map[string]error
+
+// RegisterSentinel registers sentinel errors so that decoding an error chain
+// restores them by their text, keeping errors.Is working across process
+// boundaries. It is typically called from an init function. For new errors
+// prefer Define, which gives each a stable code.
+func RegisterSentinel(errs ...error) {
+	for _, err := range errs {
+		sentinels.Store(err.Error(), err)
+	}
+}
+
+func sentinelFor(text string) error {
+	if err, ok := sentinels.Load(text); ok {
+		return err.(error)
+	}
+	return &errorString{text}
+}
+
+// wrapError is a decoded error that wrapped another error, such as one
+// created by fmt.Errorf with %w.
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (e *wrapError) Error() string { return e.msg }
+func (e *wrapError) Unwrap() error { return e.err }
+
+// joinError is a decoded error that wrapped several errors, such as one
+// created by errors.Join.
+type joinError struct {
+	msg  string
+	errs []error
+}
+
+func (e *joinError) Error() string   { return e.msg }
+func (e *joinError) Unwrap() []error { return e.errs }
+
+// kindCodes are the identifiers of each Kind on the wire. Unlike the text of
+// a Kind they must never change, or peers would decode the Kind as Other.
+var kindCodes = [...]string{
+	Other:           "other",
+	Invalid:         "invalid",
+	NotFound:        "not_found",
+	Exist:           "exist",
+	Permission:      "permission",
+	Unauthenticated: "unauthenticated",
+	Unavailable:     "unavailable",
+	Timeout:         "timeout",
+	Canceled:        "canceled",
+	Unimplemented:   "unimplemented",
+	Internal:        "internal",
+}
+
+func (k Kind) wireCode() string {
+	if int(k) < len(kindCodes) {
+		return kindCodes[k]
+	}
+	return kindCodes[Other]
+}
+
+// parseKind returns the Kind with the wire code s. The text of the Kind is
+// also accepted, as sent by peers predating the codes.
+func parseKind(s string) Kind {
+	for k, code := range kindCodes {
+		if code == s {
+			return Kind(k)
+		}
+	}
+	for k, name := range kindNames {
+		if name == s {
+			return Kind(k)
+		}
+	}
+	return Other
+}
+
+func toWireAttrs(attrs []slog.Attr) []WireAttr {
+	var out []WireAttr
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		w := WireAttr{Key: a.Key, Kind: v.Kind().String()}
+		switch v.Kind() {
+		case slog.KindGroup:
+			w.Group = toWireAttrs(v.Group())
+		case slog.KindTime:
+			w.Value = v.Time().Format(time.RFC3339Nano)
+		case slog.KindDuration:
+			w.Value = strconv.FormatInt(int64(v.Duration()), 10)
+		default:
+			w.Value = v.String()
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+func fromWireAttrs(ws []WireAttr) []slog.Attr {
+	var out []slog.Attr
+	for _, w := range ws {
+		out = append(out, slog.Attr{Key: w.Key, Value: fromWireValue(w)})
+	}
+	return out
+}
+
+func fromWireValue(w WireAttr) slog.Value {
+	switch w.Kind {
+	case slog.KindGroup.String():
+		return slog.GroupValue(fromWireAttrs(w.Group)...)
+	case slog.KindBool.String():
+		if b, err := strconv.ParseBool(w.Value); err == nil {
+			return slog.BoolValue(b)
+		}
+	case slog.KindInt64.String():
+		if i, err := strconv.ParseInt(w.Value, 10, 64); err == nil {
+			return slog.Int64Value(i)
+		}
+	case slog.KindUint64.String():
+		if u, err := strconv.ParseUint(w.Value, 10, 64); err == nil {
+			return slog.Uint64Value(u)
+		}
+	case slog.KindFloat64.String():
+		if f, err := strconv.ParseFloat(w.Value, 64); err == nil {
+			return slog.Float64Value(f)
+		}
+	case slog.KindDuration.String():
+		if d, err := strconv.ParseInt(w.Value, 10, 64); err == nil {
+			return slog.DurationValue(time.Duration(d))
+		}
+	case slog.KindTime.String():
+		if t, err := time.Parse(time.RFC3339Nano, w.Value); err == nil {
+			return slog.TimeValue(t)
+		}
+	}
+	return slog.StringValue(w.Value)
+}
+
+var _ json.Marshaler = (*Error)(nil)
+var _ json.Unmarshaler = (*Error)(nil)
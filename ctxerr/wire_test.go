@@ -0,0 +1,152 @@
+package ctxerr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+var errSentinel = errors.New("sentinel happened")
+
+func init() {
+	RegisterSentinel(errSentinel, io.EOF)
+}
+
+func TestJSON(t *testing.T) {
+	orig := E(Op("one"), NotFound, "error",
+		slog.Int("count", 3),
+		slog.Duration("wait", time.Second),
+		slog.Group("req", slog.String("id", "abc"), slog.Bool("retry", true)),
+		E(Op("two"), "error building foo", errSentinel))
+
+	data, err := json.Marshal(orig)
+	if err != nil {
+		t.Fatalf("Marshal() got error: %s", err)
+	}
+	got := &Error{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal() got error: %s", err)
+	}
+
+	if got.Error() != orig.Error() {
+		t.Errorf("Error() got %q wanted %q", got.Error(), orig.Error())
+	}
+	if !errors.Is(got, errSentinel) {
+		t.Errorf("errors.Is(got, errSentinel) got false wanted true")
+	}
+	if !errors.Is(got, NotFound) {
+		t.Errorf("errors.Is(got, NotFound) got false wanted true")
+	}
+	var e *Error
+	if !errors.As(got.Err, &e) || e.Op != "two" {
+		t.Errorf("errors.As() got %v wanted *Error with Op %q", got.Err, "two")
+	}
+	want := orig.(*Error).Attrs
+	if diff := cmp.Diff(want, got.Attrs, cmpAttrs); diff != "" {
+		t.Errorf("Attrs got diff -want/+got: %s", diff)
+	}
+}
+
+func TestWireKind(t *testing.T) {
+	if w := ToWire(E(Op("op"), NotFound)); w.Kind != "not_found" {
+		t.Errorf("ToWire() got kind %q wanted %q", w.Kind, "not_found")
+	}
+	for k := Other; k <= Internal; k++ {
+		if got := parseKind(k.wireCode()); got != k {
+			t.Errorf("parseKind(%q) got %v wanted %v", k.wireCode(), got, k)
+		}
+		// Peers predating the codes send the text of the Kind.
+		if got := parseKind(k.String()); got != k {
+			t.Errorf("parseKind(%q) got %v wanted %v", k.String(), got, k)
+		}
+	}
+}
+
+func TestWireStack(t *testing.T) {
+	w := WireOptions{Stack: true}.ToWire(E(Op("op"), "msg"))
+	if len(w.Stack) == 0 {
+		t.Fatalf("ToWire() got no stack")
+	}
+	if w.Stack[0].Func != "github.com/nveeser/srvsrv/ctxerr.TestWireStack" {
+		t.Errorf("ToWire() got innermost frame %q", w.Stack[0].Func)
+	}
+	got := FromWire(w).(*Error)
+	if diff := cmp.Diff(w.Stack, wireStack(got)); diff != "" {
+		t.Errorf("FromWire() stack got diff -want/+got: %s", diff)
+	}
+}
+
+func wireStack(err error) []WireFrame {
+	return WireOptions{Stack: true}.ToWire(err).Stack
+}
+
+func TestWireForeignWrapper(t *testing.T) {
+	orig := fmt.Errorf("handler: %w", E(Op("inner"), NotFound, "gone", slog.String("id", "7"), io.EOF))
+	data, err := json.Marshal(ToWire(orig))
+	if err != nil {
+		t.Fatalf("Marshal() got error: %s", err)
+	}
+	var w WireError
+	if err := json.Unmarshal(data, &w); err != nil {
+		t.Fatalf("Unmarshal() got error: %s", err)
+	}
+	got := FromWire(&w)
+	if got.Error() != orig.Error() {
+		t.Errorf("Error() got %q wanted %q", got.Error(), orig.Error())
+	}
+	if k := KindOf(got); k != NotFound {
+		t.Errorf("KindOf() got %v wanted %v", k, NotFound)
+	}
+	var e *Error
+	if !errors.As(got, &e) || e.Op != "inner" {
+		t.Errorf("errors.As() got %v wanted *Error with Op %q", got, "inner")
+	}
+	if v, ok := LookupAttr(got, "id", Outermost); !ok || v.String() != "7" {
+		t.Errorf("LookupAttr(id) got %v wanted %q", v, "7")
+	}
+	if !errors.Is(got, io.EOF) {
+		t.Errorf("errors.Is(got, io.EOF) got false wanted true")
+	}
+
+	joined := FromWire(ToWire(errors.Join(errSentinel, E(Op("joined"), Invalid))))
+	if !errors.Is(joined, errSentinel) || KindOf(joined) != Invalid {
+		t.Errorf("FromWire(Join) got %v wanted errSentinel and Kind %v", joined, Invalid)
+	}
+}
+
+func TestWireMulti(t *testing.T) {
+	m := NewMulti(Op("pool"), slog.String("pool", "workers"))
+	m.Add(E(Op("worker"), Unavailable, "down"))
+	m.Add(io.EOF)
+	m.Add(io.EOF)
+
+	data, err := json.Marshal(ToWire(m))
+	if err != nil {
+		t.Fatalf("Marshal() got error: %s", err)
+	}
+	var w WireError
+	if err := json.Unmarshal(data, &w); err != nil {
+		t.Fatalf("Unmarshal() got error: %s", err)
+	}
+	got, ok := FromWire(&w).(*Multi)
+	if !ok {
+		t.Fatalf("FromWire() got %T wanted *Multi", FromWire(&w))
+	}
+	if got.Op != "pool" || got.Len() != 2 {
+		t.Errorf("FromWire() got Op %q with %d errors wanted %q with 2", got.Op, got.Len(), "pool")
+	}
+	if diff := cmp.Diff(m.Attrs, got.Attrs, cmpAttrs); diff != "" {
+		t.Errorf("Attrs got diff -want/+got: %s", diff)
+	}
+	if g, w := fmt.Sprintf("%v", got), fmt.Sprintf("%v", m); g != w {
+		t.Errorf("Format() got %q wanted %q", g, w)
+	}
+	if !errors.Is(got, io.EOF) || KindOf(got) != Unavailable {
+		t.Errorf("FromWire() got %v wanted io.EOF and Kind %v", got, Unavailable)
+	}
+}
@@ -6,4 +6,7 @@ require (
 	github.com/cyrusaf/ctxlog v1.3.2
 	github.com/google/go-cmp v0.6.0
 	golang.org/x/sync v0.8.0
+	golang.org/x/tools v0.26.0
 )
+
+require golang.org/x/mod v0.21.0 // indirect
@@ -6,9 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/nveeser/srvsrv/prettylog/template"
+	"github.com/nveeser/srvsrv/redact"
 	"io"
 	"log/slog"
-	"os"
 	"runtime"
 	"slices"
 	"strconv"
@@ -52,10 +52,16 @@ type Options struct {
 	TimeFormat    string
 	Colorize      bool
 	StdOptions    slog.HandlerOptions
+	// Redact removes sensitive attributes from the output, both the
+	// template keys and the json. Values wrapped in a redact.Secret are
+	// masked even if Redact is nil.
+	Redact *redact.Policy
 }
 
 type Option = template.Option
 
+// NewPrettyHandler returns a handler that writes each record to w, formatted
+// by the OutputFormat template.
 func NewPrettyHandler(w io.Writer, opts *Options) slog.Handler {
 	if opts == nil {
 		opts = &Options{}
@@ -71,9 +77,10 @@ func NewPrettyHandler(w io.Writer, opts *Options) slog.Handler {
 	if err != nil {
 		panic(err.Error())
 	}
-	common := &common{}
+	common := &common{out: w}
 	jsonOpts := opts.StdOptions
-	jsonOpts.ReplaceAttr = suppressTemplateKeys(opts.StdOptions.ReplaceAttr, ktmpl.Keys())
+	replaceAttr := redactAttrs(opts.Redact, opts.StdOptions.ReplaceAttr)
+	jsonOpts.ReplaceAttr = suppressTemplateKeys(replaceAttr, ktmpl.Keys())
 	jsonHandler := slog.NewJSONHandler(&common.jsonBuf, &jsonOpts)
 
 	return &handler{
@@ -81,7 +88,7 @@ func NewPrettyHandler(w io.Writer, opts *Options) slog.Handler {
 		opts:        opts,
 		ktmpl:       ktmpl,
 		common:      common,
-		replaceAttr: opts.StdOptions.ReplaceAttr,
+		replaceAttr: replaceAttr,
 	}
 }
 
@@ -145,7 +152,7 @@ func (h *handler) Handle(ctx context.Context, r slog.Record) error {
 		jsonValue = colorize(darkGray, jsonValue)
 	}
 	data[JSONKey] = jsonValue
-	return h.ktmpl.Execute(os.Stdout, data)
+	return h.ktmpl.Execute(h.common.out, data)
 }
 
 func (h *handler) attributes(r slog.Record) map[string]slog.Attr {
@@ -168,6 +175,15 @@ func (h *handler) attributes(r slog.Record) map[string]slog.Attr {
 }
 
 func (h *handler) formatAttr(r slog.Record, attr slog.Attr) string {
+	if err, ok := attr.Value.Any().(error); ok {
+		// Errors (including *ctxerr.Error) render as their summary, the
+		// structured value is available in the json output.
+		value := err.Error()
+		if h.opts.Colorize {
+			value = colorize(lightRed, value)
+		}
+		return value
+	}
 	switch attr.Key {
 	case slog.TimeKey:
 		value := attr.Value.String()
@@ -234,6 +250,17 @@ func (h *handler) formatRecordLocked(ctx context.Context, r slog.Record) (string
 
 type replaceFn func([]string, slog.Attr) slog.Attr
 
+// redactAttrs applies the policy before calling next.
+func redactAttrs(p *redact.Policy, next replaceFn) replaceFn {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		a = p.ReplaceAttr(groups, a)
+		if next == nil {
+			return a
+		}
+		return next(groups, a)
+	}
+}
+
 func suppressTemplateKeys(next replaceFn, keys []string) replaceFn {
 	return func(groups []string, a slog.Attr) slog.Attr {
 		if slices.Contains(keys, a.Key) {
@@ -0,0 +1,31 @@
+package prettylog
+
+import (
+	"bytes"
+	"errors"
+	"github.com/nveeser/srvsrv/ctxerr"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestHandlerError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"Plain", errors.New("boom"), "failed: boom\n"},
+		{"Ctxerr", ctxerr.E(ctxerr.Op("load"), ctxerr.NotFound, io.EOF), "failed: load: EOF\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(NewPrettyHandler(&buf, &Options{OutputFormat: "{.msg}: {.err}\n"}))
+			logger.Error("failed", "err", tc.err)
+			if got := buf.String(); got != tc.want {
+				t.Errorf("Handle() got %q wanted %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package redact removes sensitive values from attributes before they are
+// written to logs or error messages. It is shared by ctxerr and prettylog.
+//
+// A value is sensitive if it is wrapped in a Secret, or if its attribute key
+// matches one of the Keys or Patterns of a Policy. Secrets are always
+// masked, while keys are masked only where a Policy is applied.
+package redact
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Mask is the value that replaces a redacted value.
+const Mask = "[REDACTED]"
+
+// secret is implemented by every Secret, whatever its type parameter.
+type secret interface {
+	isSecret()
+}
+
+// Secret wraps a value so that it is masked when logged, formatted or
+// marshaled to JSON. The value is only available from Reveal.
+type Secret[T any] struct {
+	v T
+}
+
+// NewSecret returns v wrapped in a Secret.
+func NewSecret[T any](v T) Secret[T] { return Secret[T]{v: v} }
+
+// Reveal returns the wrapped value.
+func (s Secret[T]) Reveal() T { return s.v }
+
+func (Secret[T]) isSecret() {}
+
+func (Secret[T]) String() string               { return Mask }
+func (Secret[T]) GoString() string             { return Mask }
+func (Secret[T]) LogValue() slog.Value         { return slog.StringValue(Mask) }
+func (Secret[T]) MarshalJSON() ([]byte, error) { return []byte(`"` + Mask + `"`), nil }
+func (Secret[T]) MarshalText() ([]byte, error) { return []byte(Mask), nil }
+
+// IsSecret reports whether v is a Secret.
+func IsSecret(v any) bool {
+	_, ok := v.(secret)
+	return ok
+}
+
+// Stage selects when ctxerr applies a Policy.
+type Stage int
+
+const (
+	// Render redacts attributes each time an error is formatted, serialized
+	// or logged, leaving the captured attributes intact for the program.
+	Render Stage = iota
+	// Capture redacts attributes once, when the error is created.
+	Capture
+)
+
+// Policy selects the attributes to redact.
+type Policy struct {
+	// Keys lists attribute keys to redact, compared without regard to case.
+	Keys []string
+	// Patterns lists expressions matched against attribute keys.
+	Patterns []*regexp.Regexp
+	// Stage selects when ctxerr applies the policy.
+	Stage Stage
+}
+
+// Default returns a policy that redacts common credentials and personal
+// data.
+func Default() *Policy {
+	return &Policy{
+		Keys: []string{"password", "passwd", "token", "secret", "authorization", "cookie", "email"},
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)(^|[_.-])(api_?key|access_?token|refresh_?token|session)$`),
+		},
+	}
+}
+
+// MatchKey reports whether values with the key are redacted.
+func (p *Policy) MatchKey(key string) bool {
+	if p == nil {
+		return false
+	}
+	if slices.ContainsFunc(p.Keys, func(k string) bool { return strings.EqualFold(k, key) }) {
+		return true
+	}
+	return slices.ContainsFunc(p.Patterns, func(re *regexp.Regexp) bool { return re.MatchString(key) })
+}
+
+// Attr returns a with its value replaced by Mask if the key matches the
+// policy or the value is a Secret. Members of groups are redacted
+// recursively. A nil *Policy only masks Secrets.
+func (p *Policy) Attr(a slog.Attr) slog.Attr {
+	if p.MatchKey(a.Key) || IsSecret(a.Value.Any()) {
+		return slog.String(a.Key, Mask)
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		if !p.anyRedacted(group) {
+			return a
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(p.Attrs(group)...)}
+	}
+	return a
+}
+
+// Attrs returns the attributes redacted with Attr. The slice is returned
+// unchanged if nothing is redacted.
+func (p *Policy) Attrs(attrs []slog.Attr) []slog.Attr {
+	if !p.anyRedacted(attrs) {
+		return attrs
+	}
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		out[i] = p.Attr(a)
+	}
+	return out
+}
+
+func (p *Policy) anyRedacted(attrs []slog.Attr) bool {
+	for _, a := range attrs {
+		if p.MatchKey(a.Key) || IsSecret(a.Value.Any()) {
+			return true
+		}
+		if a.Value.Kind() == slog.KindGroup && p.anyRedacted(a.Value.Group()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceAttr redacts attributes for slog.HandlerOptions.ReplaceAttr. An
+// attribute is also redacted if any enclosing group matches the policy.
+func (p *Policy) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if slices.ContainsFunc(groups, p.MatchKey) {
+		return slog.String(a.Key, Mask)
+	}
+	return p.Attr(a)
+}
@@ -0,0 +1,89 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPolicyAttr(t *testing.T) {
+	p := &Policy{
+		Keys:     []string{"password"},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`_token$`)},
+	}
+	cases := []struct {
+		name string
+		in   slog.Attr
+		want slog.Attr
+	}{
+		{"Key", slog.String("Password", "hunter2"), slog.String("Password", Mask)},
+		{"Pattern", slog.String("api_token", "abc"), slog.String("api_token", Mask)},
+		{"Secret", slog.Any("card", NewSecret("4111")), slog.String("card", Mask)},
+		{"Kept", slog.String("user", "bob"), slog.String("user", "bob")},
+		{
+			"Group",
+			slog.Group("req", slog.String("user", "bob"), slog.String("password", "x")),
+			slog.Group("req", slog.String("user", "bob"), slog.String("password", Mask)),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := p.Attr(tc.in); !got.Equal(tc.want) {
+				t.Errorf("Attr() got %v wanted %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNilPolicy(t *testing.T) {
+	var p *Policy
+	attrs := []slog.Attr{slog.String("password", "x"), slog.Any("s", NewSecret(1))}
+	got := p.Attrs(attrs)
+	if got[0].Value.String() != "x" || got[1].Value.String() != Mask {
+		t.Errorf("Attrs() got %v wanted only the Secret masked", got)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	s := NewSecret("hunter2")
+	if s.Reveal() != "hunter2" {
+		t.Errorf("Reveal() got %q wanted %q", s.Reveal(), "hunter2")
+	}
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		if got := fmt.Sprintf(verb, s); got != Mask {
+			t.Errorf("Sprintf(%s) got %q wanted %q", verb, got, Mask)
+		}
+	}
+	b, err := json.Marshal(map[string]any{"s": s})
+	if err != nil || string(b) != `{"s":"[REDACTED]"}` {
+		t.Errorf("Marshal() got %s, %v", b, err)
+	}
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("msg", "s", s)
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("slog got %s wanted masked", buf.String())
+	}
+}
+
+func TestReplaceAttr(t *testing.T) {
+	var buf bytes.Buffer
+	opts := &slog.HandlerOptions{ReplaceAttr: Default().ReplaceAttr}
+	slog.New(slog.NewJSONHandler(&buf, opts)).Info("msg",
+		"email", "bob@example.com",
+		slog.Group("secret", slog.String("a", "1")),
+		"user_session", "abc",
+		"user", "bob")
+	out := buf.String()
+	for _, leak := range []string{"bob@example.com", `"a":"1"`, "abc"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("output got %s wanted %s redacted", out, leak)
+		}
+	}
+	if !strings.Contains(out, `"user":"bob"`) {
+		t.Errorf("output got %s wanted user kept", out)
+	}
+}
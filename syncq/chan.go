@@ -0,0 +1,83 @@
+package syncq
+
+import (
+	"context"
+)
+
+// FromChan returns a new Queue containing the values received from ch. The
+// returned Queue is closed once ch is closed or the context expires, matching
+// a producer that has finished.
+//
+// If the Queue is shutdown, values are no longer received from ch and the
+// internal goroutine exits; any values remaining in ch are left unread.
+func FromChan[E any](ctx context.Context, ch <-chan E) *Queue[E] {
+	q := New[E]()
+	go func() {
+		defer q.Close()
+		for {
+			select {
+			case e, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := q.Push(ctx, e); err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-q.shutdown:
+				return
+			}
+		}
+	}()
+	return q
+}
+
+// lease returns an element held by a Chan() adapter to the queue goroutine,
+// which puts it back at the front of the queue if it was not delivered.
+type lease[E any] struct {
+	element E
+	requeue bool
+}
+
+// Chan returns a channel that receives the values popped from the Queue. The
+// channel is closed once the Queue is closed and empty, or shutdown.
+//
+// The context signals that the reader of the channel is finished. Once it
+// expires the channel is closed and no more values are popped, though the Queue
+// itself is not shutdown since other consumers may still be calling Pop().
+// A value is only removed from the Queue once the reader receives it: a value
+// held for a reader that finished is put back at the front of the Queue, or
+// returned by ShutdownRemaining() if the Queue is shutdown.
+func (q *Queue[E]) Chan(ctx context.Context) <-chan E {
+	out := make(chan E)
+	go func() {
+		defer close(out)
+		for ctx.Err() == nil {
+			var e E
+			select {
+			case v, ok := <-q.leasec:
+				if !ok {
+					return
+				}
+				e = v
+			case <-ctx.Done():
+				return
+			case <-q.shutdown:
+				return
+			}
+			select {
+			case out <- e:
+				q.size.Add(-1)
+				q.returnc <- lease[E]{}
+			case <-ctx.Done():
+				q.returnc <- lease[E]{element: e, requeue: true}
+				return
+			case <-q.shutdown:
+				q.returnc <- lease[E]{element: e, requeue: true}
+				return
+			}
+		}
+	}()
+	return out
+}
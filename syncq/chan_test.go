@@ -0,0 +1,149 @@
+package syncq
+
+import (
+	"context"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"time"
+)
+
+func TestFromChan(t *testing.T) {
+	t.Run("CloseOnChannelClose", func(t *testing.T) {
+		ctx := context.Background()
+		ch := make(chan int, 3)
+		ch <- 1
+		ch <- 2
+		ch <- 3
+		close(ch)
+
+		q := FromChan(ctx, ch)
+		var got []int
+		for {
+			v, ok := q.Pop(ctx)
+			if !ok {
+				break
+			}
+			got = append(got, v)
+		}
+		if diff := cmp.Diff([]int{1, 2, 3}, got); diff != "" {
+			t.Errorf("Pop() got diff -want/+got: %s", diff)
+		}
+	})
+	t.Run("CloseOnCancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		q := FromChan(ctx, make(chan int))
+		cancel()
+
+		if !q.WaitEmpty(waitCtx(t)) {
+			t.Errorf("WaitEmpty() got false wanted true")
+		}
+	})
+	t.Run("StopOnShutdown", func(t *testing.T) {
+		ch := make(chan int)
+		q := FromChan(context.Background(), ch)
+		q.Shutdown()
+		time.Sleep(10 * time.Millisecond) // let the goroutine observe Shutdown()
+
+		select {
+		case ch <- 1:
+			t.Errorf("channel still read after Shutdown()")
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+}
+
+func TestChan(t *testing.T) {
+	t.Run("CloseOnQueueClose", func(t *testing.T) {
+		ctx := context.Background()
+		q := New[int]()
+		defer q.WaitEmpty(ctx)
+		pushAll(t, q, 1, 2, 3)
+
+		var got []int
+		for v := range q.Chan(ctx) {
+			got = append(got, v)
+		}
+		if diff := cmp.Diff([]int{1, 2, 3}, got); diff != "" {
+			t.Errorf("Chan() got diff -want/+got: %s", diff)
+		}
+	})
+	t.Run("CloseOnShutdown", func(t *testing.T) {
+		q := New[int]()
+		ch := q.Chan(context.Background())
+		q.Shutdown()
+		expectClosed(t, ch)
+	})
+	t.Run("CloseOnCancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		q := New[int]()
+		defer q.WaitEmpty(context.Background())
+		ch := q.Chan(ctx)
+		cancel()
+		expectClosed(t, ch)
+	})
+	t.Run("CancelKeepsHeldValue", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		q := New[int]()
+		pushAll(t, q, 1, 2)
+		ch := q.Chan(ctx)
+		waitHeld(t, q, 1)
+		cancel()
+		waitHeld(t, q, 0)
+		expectClosed(t, ch)
+
+		var got []int
+		for {
+			v, ok := q.Pop(waitCtx(t))
+			if !ok {
+				break
+			}
+			got = append(got, v)
+		}
+		if diff := cmp.Diff([]int{1, 2}, got); diff != "" {
+			t.Errorf("Pop() got diff -want/+got: %s", diff)
+		}
+	})
+	t.Run("ShutdownKeepsHeldValue", func(t *testing.T) {
+		q := New[int]()
+		pushAll(t, q, 1, 2)
+		ch := q.Chan(context.Background())
+		waitHeld(t, q, 1)
+
+		if diff := cmp.Diff([]int{1, 2}, q.ShutdownRemaining()); diff != "" {
+			t.Errorf("ShutdownRemaining() got diff -want/+got: %s", diff)
+		}
+		expectClosed(t, ch)
+	})
+}
+
+// waitHeld waits until the Chan() adapter holds n values, that is until only
+// the others are left in the queue.
+func waitHeld(t *testing.T, q *Queue[int], n int) {
+	t.Helper()
+	size, _ := q.Size()
+	for len(q.Snapshot()) != int(size)-n {
+		select {
+		case <-waitCtx(t).Done():
+			t.Fatalf("Chan() did not take a value")
+		default:
+		}
+	}
+}
+
+func waitCtx(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func expectClosed[E any](t *testing.T, ch <-chan E) {
+	t.Helper()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("channel got value wanted closed")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Errorf("timeout waiting for channel to close")
+	}
+}
@@ -0,0 +1,189 @@
+package syncq
+
+import (
+	"context"
+	"github.com/nveeser/srvsrv/ctxerr"
+	"sync"
+)
+
+// Future holds the result of a request pushed to a RequestQueue. The result is
+// set exactly once, either by the consumer of the request or when the request
+// times out or the queue is shutdown.
+type Future[R any] struct {
+	once  sync.Once
+	done  chan any
+	value R
+	err   error
+	// forget removes the Future from the pending set of its RequestQueue.
+	forget func()
+}
+
+func newFuture[R any]() *Future[R] {
+	return &Future[R]{done: make(chan any)}
+}
+
+// Done returns a channel that is closed once the result is available.
+func (f *Future[R]) Done() <-chan any { return f.done }
+
+// Wait blocks until the result is available or the context expires. If the
+// context expires first the Future is resolved with the context error and any
+// later reply by the consumer is dropped.
+func (f *Future[R]) Wait(ctx context.Context) (R, error) {
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		if f.resolve(*new(R), ctxerr.E(ctxerr.Op("syncq.Future.Wait"), ctx, ctx.Err())) && f.forget != nil {
+			f.forget()
+		}
+	}
+	return f.value, f.err
+}
+
+// resolve sets the result if it has not already been set and returns true if
+// this call set the value.
+func (f *Future[R]) resolve(v R, err error) (ok bool) {
+	f.once.Do(func() {
+		f.value, f.err = v, err
+		close(f.done)
+		ok = true
+	})
+	return ok
+}
+
+// Request is a value popped from a RequestQueue. The consumer must call Reply
+// or Fail exactly once to complete the Future held by the producer.
+type Request[T, R any] struct {
+	Value  T
+	future *Future[R]
+	q      *RequestQueue[T, R]
+}
+
+// Reply completes the request with the specified value. It returns false if
+// the request had already been resolved, for example by a timeout.
+func (r *Request[T, R]) Reply(v R) bool { return r.complete(v, nil) }
+
+// Fail completes the request with the specified error. It returns false if the
+// request had already been resolved, for example by a timeout.
+func (r *Request[T, R]) Fail(err error) bool { return r.complete(*new(R), err) }
+
+// Done returns a channel that is closed once the request has been resolved.
+// Consumers can use it to abandon work whose producer is no longer waiting.
+func (r *Request[T, R]) Done() <-chan any { return r.future.Done() }
+
+func (r *Request[T, R]) complete(v R, err error) bool {
+	defer r.q.forget(r.future)
+	return r.future.resolve(v, err)
+}
+
+// RequestQueue is a Queue of requests whose producers wait for a reply.
+// Producers call Push() and wait on the returned Future, consumers call Pop()
+// and complete each Request with Reply() or Fail().
+//
+// Close() and Shutdown() follow the same rules as Queue. In addition Shutdown()
+// resolves every outstanding Future with an error wrapping ErrQueueShutdown.
+type RequestQueue[T, R any] struct {
+	q        *Queue[*Request[T, R]]
+	mu       sync.Mutex
+	pending  map[*Future[R]]bool
+	shutdown bool
+}
+
+// NewRequestQueue returns a new initialized RequestQueue.
+func NewRequestQueue[T, R any]() *RequestQueue[T, R] {
+	return &RequestQueue[T, R]{
+		q:       New[*Request[T, R]](),
+		pending: make(map[*Future[R]]bool),
+	}
+}
+
+// Size returns the current number of queued requests followed by the total
+// number of requests that have been processed by the queue.
+func (q *RequestQueue[T, R]) Size() (size, total int64) { return q.q.Size() }
+
+// Push adds a request for the specified value and returns the Future that
+// will hold the reply. If the context expires before the value can be enqueued
+// or the queue is shutdown an error is returned.
+func (q *RequestQueue[T, R]) Push(ctx context.Context, v T) (*Future[R], error) {
+	f := newFuture[R]()
+	f.forget = func() { q.forget(f) }
+	q.mu.Lock()
+	if q.shutdown {
+		q.mu.Unlock()
+		return nil, ctxerr.E(ctxerr.Op("syncq.RequestQueue.Push"), ErrQueueShutdown)
+	}
+	q.pending[f] = true
+	q.mu.Unlock()
+
+	if err := q.q.Push(ctx, &Request[T, R]{Value: v, future: f, q: q}); err != nil {
+		q.forget(f)
+		return nil, ctxerr.E(ctxerr.Op("syncq.RequestQueue.Push"), err)
+	}
+	// The queue may accept the request while shutting down, resolve it
+	// here in case Shutdown no longer sees it.
+	q.mu.Lock()
+	shutdown := q.shutdown
+	q.mu.Unlock()
+	if shutdown {
+		q.forget(f)
+		f.resolve(*new(R), ctxerr.E(ctxerr.Op("syncq.RequestQueue.Push"), ErrQueueShutdown))
+	}
+	return f, nil
+}
+
+// Pop returns the next request in the queue following the same rules as
+// Queue.Pop(). Requests already resolved while queued, for example by a
+// timeout, are skipped.
+func (q *RequestQueue[T, R]) Pop(ctx context.Context) (*Request[T, R], bool) {
+	for {
+		r, ok := q.q.Pop(ctx)
+		if !ok {
+			return nil, false
+		}
+		select {
+		case <-r.Done():
+			q.forget(r.future)
+			continue
+		default:
+			return r, true
+		}
+	}
+}
+
+// Close marks the queue as closed and signals that no more requests are going
+// to be added.
+func (q *RequestQueue[T, R]) Close() { q.q.Close() }
+
+// Shutdown shuts down the queue and resolves every outstanding Future, both
+// queued and popped but not yet replied to, with an error wrapping
+// ErrQueueShutdown.
+func (q *RequestQueue[T, R]) Shutdown() {
+	q.q.Shutdown()
+
+	q.mu.Lock()
+	q.shutdown = true
+	pending := q.pending
+	q.pending = make(map[*Future[R]]bool)
+	q.mu.Unlock()
+
+	err := ctxerr.E(ctxerr.Op("syncq.RequestQueue.Shutdown"), ErrQueueShutdown)
+	for f := range pending {
+		f.resolve(*new(R), err)
+	}
+}
+
+// WaitEmpty blocks until the queue is empty or the context is canceled. If the
+// context is canceled the queue is shutdown and outstanding futures are
+// resolved with an error.
+func (q *RequestQueue[T, R]) WaitEmpty(ctx context.Context) bool {
+	if q.q.WaitEmpty(ctx) {
+		return true
+	}
+	q.Shutdown()
+	return false
+}
+
+func (q *RequestQueue[T, R]) forget(f *Future[R]) {
+	q.mu.Lock()
+	delete(q.pending, f)
+	q.mu.Unlock()
+}
@@ -0,0 +1,159 @@
+package syncq
+
+import (
+	"context"
+	"errors"
+	"github.com/nveeser/srvsrv/ctxerr"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestQueue(t *testing.T) {
+	t.Run("Reply", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewRequestQueue[int, string]()
+		defer q.WaitEmpty(ctx)
+
+		go func() {
+			r, ok := q.Pop(ctx)
+			if ok {
+				r.Reply("three")
+			}
+		}()
+
+		f, err := q.Push(ctx, 3)
+		if err != nil {
+			t.Fatalf("Push() got error: %s", err)
+		}
+		got, err := f.Wait(waitCtx(t))
+		if err != nil || got != "three" {
+			t.Errorf("Wait() got (%q, %v) wanted (%q, nil)", got, err, "three")
+		}
+	})
+	t.Run("Fail", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewRequestQueue[int, string]()
+		defer q.WaitEmpty(ctx)
+		wantErr := errors.New("fake error")
+
+		go func() {
+			r, ok := q.Pop(ctx)
+			if ok {
+				r.Fail(wantErr)
+			}
+		}()
+
+		f, err := q.Push(ctx, 3)
+		if err != nil {
+			t.Fatalf("Push() got error: %s", err)
+		}
+		if _, err := f.Wait(waitCtx(t)); !errors.Is(err, wantErr) {
+			t.Errorf("Wait() got err %v wanted %v", err, wantErr)
+		}
+	})
+	t.Run("Timeout", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewRequestQueue[int, string]()
+		defer q.Shutdown()
+
+		f, err := q.Push(ctx, 3)
+		if err != nil {
+			t.Fatalf("Push() got error: %s", err)
+		}
+		wctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = f.Wait(wctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Wait() got err %v wanted %v", err, context.DeadlineExceeded)
+		}
+		checkOp(t, err, "syncq.Future.Wait")
+		q.mu.Lock()
+		pending := len(q.pending)
+		q.mu.Unlock()
+		if pending != 0 {
+			t.Errorf("pending got %d wanted 0", pending)
+		}
+
+		// The timed out request is skipped by consumers.
+		pctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if r, ok := q.Pop(pctx); ok {
+			t.Errorf("Pop() got request %v wanted none", r.Value)
+		}
+	})
+	t.Run("Shutdown", func(t *testing.T) {
+		ctx := context.Background()
+		q := NewRequestQueue[int, string]()
+
+		queued, err := q.Push(ctx, 1)
+		if err != nil {
+			t.Fatalf("Push() got error: %s", err)
+		}
+		inflight, err := q.Push(ctx, 2)
+		if err != nil {
+			t.Fatalf("Push() got error: %s", err)
+		}
+		r, _ := q.Pop(ctx)
+		q.Shutdown()
+
+		for _, f := range []*Future[string]{queued, inflight} {
+			_, err := f.Wait(waitCtx(t))
+			if !errors.Is(err, ErrQueueShutdown) {
+				t.Errorf("Wait() got err %v wanted %v", err, ErrQueueShutdown)
+			}
+			checkOp(t, err, "syncq.RequestQueue.Shutdown")
+		}
+		if r.Reply("late") {
+			t.Errorf("Reply() after Shutdown() got true wanted false")
+		}
+		if _, err := q.Push(ctx, 3); !errors.Is(err, ErrQueueShutdown) {
+			t.Errorf("Push() got err %v wanted %v", err, ErrQueueShutdown)
+		}
+	})
+}
+
+func TestRequestQueuePushShutdown(t *testing.T) {
+	ctx := context.Background()
+	for range 500 {
+		q := NewRequestQueue[int, string]()
+		var wg sync.WaitGroup
+		futures := make(chan *Future[string], 200)
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 25 {
+					if f, err := q.Push(ctx, i); err == nil {
+						futures <- f
+					}
+				}
+			}()
+		}
+		q.Shutdown()
+		wg.Wait()
+		close(futures)
+
+		for f := range futures {
+			select {
+			case <-f.Done():
+			case <-time.After(time.Second):
+				t.Fatalf("Future not resolved after Shutdown()")
+			}
+			if _, err := f.Wait(ctx); !errors.Is(err, ErrQueueShutdown) {
+				t.Errorf("Wait() got err %v wanted %v", err, ErrQueueShutdown)
+			}
+		}
+	}
+}
+
+func checkOp(t *testing.T, err error, want ctxerr.Op) {
+	t.Helper()
+	var e *ctxerr.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("error %v is not a *ctxerr.Error", err)
+	}
+	if e.Op != want {
+		t.Errorf("error got Op %q wanted %q", e.Op, want)
+	}
+}
@@ -0,0 +1,199 @@
+package syncq
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// PopAny returns the next item available from any of the specified queues
+// along with the index of the queue that provided it. Like a select statement,
+// if more than one queue has an item available one is chosen at random.
+//
+// Queues that are closed and empty (or shutdown) are dropped from the set. Once
+// all queues are closed, or the context expires, PopAny returns -1, the zero
+// value and false.
+func PopAny[E any](ctx context.Context, queues ...*Queue[E]) (index int, element E, open bool) {
+	active := make([]bool, len(queues))
+	for i := range active {
+		active[i] = true
+	}
+	return popActive(ctx, queues, active)
+}
+
+// popActive blocks until an element is available from one of the queues
+// marked as active. Queues which are found to be closed are marked inactive.
+func popActive[E any](ctx context.Context, queues []*Queue[E], active []bool) (int, E, bool) {
+	var zero E
+	cases := make([]reflect.SelectCase, 0, len(queues)+1)
+	index := make([]int, 0, len(queues)+1)
+
+	cases = append(cases, reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(ctx.Done()),
+	})
+	index = append(index, -1)
+	for i, q := range queues {
+		if !active[i] {
+			continue
+		}
+		cases = append(cases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(q.popc),
+		})
+		index = append(index, i)
+	}
+
+	for len(cases) > 1 {
+		chosen, v, ok := reflect.Select(cases)
+		if chosen == 0 {
+			return -1, zero, false
+		}
+		i := index[chosen]
+		if !ok {
+			// Queue is closed and empty, drop it from the set.
+			active[i] = false
+			cases = slices.Delete(cases, chosen, chosen+1)
+			index = slices.Delete(index, chosen, chosen+1)
+			continue
+		}
+		queues[i].size.Add(-1)
+		return i, v.Interface().(E), true
+	}
+	return -1, zero, false
+}
+
+// tryReply is the answer of the queue goroutine to tryPop.
+type tryReply[E any] struct {
+	element E
+	ok      bool
+}
+
+// tryPop returns the next item in the queue if one is available, without
+// waiting for one to be pushed. Unlike a non-blocking receive it asks the
+// queue goroutine, so an item is found even if the goroutine is not yet
+// offering it. If the queue is closed and empty, or shutdown, closed is true.
+func (q *Queue[E]) tryPop() (element E, ok, closed bool) {
+	reply := make(chan tryReply[E], 1)
+	select {
+	case q.tryc <- reply:
+		r := <-reply
+		if r.ok {
+			q.size.Add(-1)
+		}
+		return r.element, r.ok, false
+	case <-q.done:
+		return element, false, true
+	}
+}
+
+// Selector receives values from multiple queues, sharing consumers across
+// queues in proportion to their weight. When items are available on more than
+// one queue, a queue with weight 3 is chosen three times as often as a queue
+// with weight 1. Queues with equal weights are consumed round-robin.
+//
+// The weighting applies to the items already in the queues when Pop is
+// called. If all queues are empty Pop waits and returns the first item pushed
+// to any of them.
+//
+// Like PopAny, queues which are closed and empty drop out of the selection.
+// Selector is safe for use by multiple concurrent consumers.
+type Selector[E any] struct {
+	mu      sync.Mutex
+	queues  []*Queue[E]
+	weights []int
+	current []int
+	active  []bool
+}
+
+// NewSelector returns a Selector across the specified queues, each with
+// a weight of 1.
+func NewSelector[E any](queues ...*Queue[E]) *Selector[E] {
+	s := &Selector[E]{}
+	for _, q := range queues {
+		s.Add(q, 1)
+	}
+	return s
+}
+
+// Add adds the queue to the Selector with the specified weight and returns
+// the index that Pop() will report for values from this queue. A weight less
+// than 1 is treated as 1.
+func (s *Selector[E]) Add(q *Queue[E], weight int) int {
+	if weight < 1 {
+		weight = 1
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queues = append(s.queues, q)
+	s.weights = append(s.weights, weight)
+	s.current = append(s.current, 0)
+	s.active = append(s.active, true)
+	return len(s.queues) - 1
+}
+
+// Pop returns the next item from the queues along with the index of the queue
+// that provided it. If no item is available the call blocks until one is. If
+// all queues are closed and empty, or the specified context expires, then -1,
+// the zero value and false are returned.
+func (s *Selector[E]) Pop(ctx context.Context) (index int, element E, open bool) {
+	s.mu.Lock()
+	order := s.nextOrderLocked()
+	queues := slices.Clone(s.queues)
+	active := slices.Clone(s.active)
+	s.mu.Unlock()
+
+	// Prefer queues in weighted order when items are already available.
+	index = -1
+	for _, i := range order {
+		v, ok, closed := queues[i].tryPop()
+		if closed {
+			active[i] = false
+			continue
+		}
+		if ok {
+			index, element, open = i, v, true
+			break
+		}
+	}
+	if index < 0 {
+		index, element, open = popActive(ctx, queues, active)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ok := range active {
+		s.active[i] = s.active[i] && ok
+	}
+	if open {
+		s.current[index] -= s.totalLocked()
+	}
+	return index, element, open
+}
+
+// nextOrderLocked advances the smooth weighted round-robin state and returns
+// the active queue indexes from most to least deserving.
+func (s *Selector[E]) nextOrderLocked() []int {
+	var order []int
+	for i, ok := range s.active {
+		if !ok {
+			continue
+		}
+		s.current[i] += s.weights[i]
+		order = append(order, i)
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return s.current[b] - s.current[a]
+	})
+	return order
+}
+
+func (s *Selector[E]) totalLocked() (total int) {
+	for i, ok := range s.active {
+		if ok {
+			total += s.weights[i]
+		}
+	}
+	return total
+}
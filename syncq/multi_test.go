@@ -0,0 +1,113 @@
+package syncq
+
+import (
+	"context"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"time"
+)
+
+// pushAll pushes the values onto the queue and then closes it.
+func pushAll(t *testing.T, q *Queue[int], values ...int) {
+	t.Helper()
+	for _, v := range values {
+		if err := q.Push(context.Background(), v); err != nil {
+			t.Fatalf("Push() got error: %s", err)
+		}
+	}
+	q.Close()
+}
+
+func TestPopAny(t *testing.T) {
+	t.Run("AllValues", func(t *testing.T) {
+		ctx := context.Background()
+		q1, q2 := New[int](), New[int]()
+		pushAll(t, q1, 1, 2, 3)
+		pushAll(t, q2, 10, 20)
+
+		got := map[int][]int{}
+		for {
+			i, v, ok := PopAny(ctx, q1, q2)
+			if !ok {
+				if i != -1 {
+					t.Errorf("PopAny() got index %d wanted -1", i)
+				}
+				break
+			}
+			got[i] = append(got[i], v)
+		}
+		want := map[int][]int{0: {1, 2, 3}, 1: {10, 20}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("PopAny() got diff -want/+got: %s", diff)
+		}
+	})
+	t.Run("ClosedQueueDropsOut", func(t *testing.T) {
+		ctx := context.Background()
+		q1, q2 := New[int](), New[int]()
+		defer q2.WaitEmpty(ctx)
+		q1.Close()
+
+		go q2.Push(ctx, 5)
+		i, v, ok := PopAny(ctx, q1, q2)
+		if i != 1 || v != 5 || !ok {
+			t.Errorf("PopAny() got (%d, %d, %t) wanted (%d, %d, %t)", i, v, ok, 1, 5, true)
+		}
+	})
+	t.Run("BlockUntilCancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		q1, q2 := New[int](), New[int]()
+		defer q1.WaitEmpty(context.Background())
+		defer q2.WaitEmpty(context.Background())
+
+		i, v, ok := PopAny(ctx, q1, q2)
+		if i != -1 || v != 0 || ok {
+			t.Errorf("PopAny() got (%d, %d, %t) wanted (%d, %d, %t)", i, v, ok, -1, 0, false)
+		}
+	})
+}
+
+func TestSelector(t *testing.T) {
+	t.Run("Weighted", func(t *testing.T) {
+		ctx := context.Background()
+		q1, q2 := New[int](), New[int]()
+		pushAll(t, q1, make([]int, 20)...)
+		pushAll(t, q2, make([]int, 20)...)
+
+		s := &Selector[int]{}
+		s.Add(q1, 2)
+		s.Add(q2, 1)
+
+		var got [2]int
+		for i := 0; i < 9; i++ {
+			idx, _, ok := s.Pop(ctx)
+			if !ok {
+				t.Fatalf("Pop() got closed")
+			}
+			got[idx]++
+		}
+		if want := [2]int{6, 3}; got != want {
+			t.Errorf("Pop() got counts %v wanted %v", got, want)
+		}
+	})
+	t.Run("RoundRobin", func(t *testing.T) {
+		ctx := context.Background()
+		q1, q2 := New[int](), New[int]()
+		pushAll(t, q1, 1, 2, 3)
+		pushAll(t, q2, 10, 20, 30)
+
+		s := NewSelector(q1, q2)
+		var got []int
+		for {
+			_, v, ok := s.Pop(ctx)
+			if !ok {
+				break
+			}
+			got = append(got, v)
+		}
+		want := []int{1, 10, 2, 20, 3, 30}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Pop() got diff -want/+got: %s", diff)
+		}
+	})
+}
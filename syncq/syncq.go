@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"github.com/nveeser/srvsrv/ctxerr"
+	"slices"
 	"sync/atomic"
 )
 
@@ -26,13 +27,25 @@ import (
 //   - Consumers call Pop()
 //   - Close() signals to consumers that Push() will no longer be called
 //   - Shutdown() signals to producers that Pop() will no longer be called
+//   - Pause() and Resume() temporarily stop and restart delivery to consumers
+//   - Drain() closes the Queue and returns what was not consumed by a deadline
+//   - ShutdownRemaining() and Snapshot() return undelivered elements
 type Queue[E any] struct {
 	pushc    chan E
 	popc     chan E
+	pausec   chan bool
+	snapc    chan chan []E
+	tryc     chan chan tryReply[E]
+	leasec   chan E
+	returnc  chan lease[E]
 	shutdown chan any
 	done     chan any
 	size     atomic.Int64
 	total    atomic.Int64
+
+	// remaining holds the elements not yet consumed when the queue was
+	// shutdown. Only valid once done is closed.
+	remaining []E
 }
 
 // New returns a new initialized Queue. The caller is responsible for calling
@@ -44,6 +57,11 @@ func New[E any]() *Queue[E] {
 	q := &Queue[E]{
 		pushc:    make(chan E),
 		popc:     make(chan E),
+		pausec:   make(chan bool),
+		snapc:    make(chan chan []E),
+		tryc:     make(chan chan tryReply[E]),
+		leasec:   make(chan E),
+		returnc:  make(chan lease[E]),
 		shutdown: make(chan any),
 		done:     make(chan any),
 	}
@@ -63,7 +81,7 @@ var ErrQueueShutdown = errors.New("Queue is shutdown")
 
 // Push adds the specified value to the queue. If the context expires before the
 // value can be enqueued then an error is returned. If the queue has been
-// canceled the queue returns ErrQueueCanceled. Calling Push() after
+// shutdown the queue returns ErrQueueShutdown. Calling Push() after
 // Close() will panic.
 func (q *Queue[E]) Push(ctx context.Context, e E) error {
 	select {
@@ -107,18 +125,71 @@ func (q *Queue[E]) Close() { closeOnce(q.pushc) }
 // will return zero value and false
 func (q *Queue[E]) Shutdown() { closeOnce(q.shutdown) }
 
+// ShutdownRemaining shuts down the queue like Shutdown() and returns the
+// elements that were not delivered to consumers. If the queue was already
+// closed and empty nil is returned.
+func (q *Queue[E]) ShutdownRemaining() []E {
+	q.Shutdown()
+	<-q.done
+	return slices.Clone(q.remaining)
+}
+
+// Snapshot returns a copy of the elements currently in the queue, in the order
+// they would be delivered. The queue itself is not modified. Once the queue is
+// done, Snapshot returns the elements left at shutdown, if any.
+func (q *Queue[E]) Snapshot() []E {
+	reply := make(chan []E, 1)
+	select {
+	case q.snapc <- reply:
+		return <-reply
+	case <-q.done:
+		return slices.Clone(q.remaining)
+	}
+}
+
 // WaitEmpty blocks until the Queue is empty or the context
 // is canceled. If the context is canceled the queue is shutdown
 // and any remaining values are not guaranteed to be processed.
+// Use Drain() to receive the remaining values instead.
 func (q *Queue[E]) WaitEmpty(ctx context.Context) bool {
+	_, drained := q.Drain(ctx)
+	return drained
+}
+
+// Pause stops delivery of elements to consumers. While paused, calls to Pop()
+// block but Push() continues to accept elements. Once Pause returns no further
+// elements are delivered until Resume() is called.
+//
+// A Queue that is closed and empty still reports closed to consumers while
+// paused.
+func (q *Queue[E]) Pause() { q.setPaused(true) }
+
+// Resume restarts delivery of elements to consumers after Pause().
+func (q *Queue[E]) Resume() { q.setPaused(false) }
+
+func (q *Queue[E]) setPaused(paused bool) {
+	select {
+	case q.pausec <- paused:
+	case <-q.done:
+	}
+}
+
+// Drain closes the Queue and blocks until all elements are consumed or the
+// context expires. If the context expires the queue is shutdown and the
+// elements that were not consumed are returned along with false, instead of
+// being discarded.
+//
+// Drain does not Resume() a paused Queue, so a paused Queue will not drain
+// before the context expires.
+func (q *Queue[E]) Drain(ctx context.Context) (remaining []E, drained bool) {
 	q.Close()
 	select {
 	case <-q.done:
-		return true
+		return nil, true
 	case <-ctx.Done():
 		q.Shutdown()
 		<-q.done
-		return false
+		return slices.Clone(q.remaining), false
 	}
 }
 
@@ -134,14 +205,21 @@ func closeOnce[E any](c chan E) {
 func (q *Queue[E]) goqueue() {
 	defer close(q.done)
 	defer close(q.popc)
+	defer close(q.leasec)
 
 	var queue []E
-	var next E
+	var paused bool
+	var leased int   // elements held by Chan() adapters, see lease
 	var pushc chan E // nil when once queue is closed
-	var popc chan E  // nil when popc is ready to send
 	pushc = q.pushc
 
 	for {
+		var next E
+		var popc, leasec chan E // nil when there is nothing to send or paused
+		if len(queue) > 0 && !paused {
+			next, popc, leasec = queue[0], q.popc, q.leasec
+		}
+
 		select {
 		case e, ok := <-pushc:
 			if ok {
@@ -151,24 +229,46 @@ func (q *Queue[E]) goqueue() {
 			}
 
 		case popc <- next:
-			popc = nil
+			queue = queue[1:]
+
+		case leasec <- next:
+			queue = queue[1:]
+			leased++
+
+		case l := <-q.returnc:
+			leased--
+			if l.requeue {
+				queue = slices.Insert(queue, 0, l.element)
+			}
+
+		case paused = <-q.pausec:
+
+		case reply := <-q.snapc:
+			reply <- slices.Clone(queue)
+
+		case reply := <-q.tryc:
+			if len(queue) > 0 && !paused {
+				reply <- tryReply[E]{queue[0], true}
+				queue = queue[1:]
+			} else {
+				reply <- tryReply[E]{}
+			}
 
 		case <-q.shutdown:
+			// Wait for the adapters to return what they hold.
+			for ; leased > 0; leased-- {
+				if l := <-q.returnc; l.requeue {
+					queue = slices.Insert(queue, 0, l.element)
+				}
+			}
+			q.remaining = queue
+			q.size.Add(-int64(len(queue)))
 			return
 		}
-		empty := len(queue) == 0 && popc == nil
-		closed := pushc == nil
-		popReady := popc == nil
 
-		switch {
-		// input channel is closed and queue empty
-		case closed && empty:
+		// input channel is closed, queue empty and nothing leased
+		if pushc == nil && len(queue) == 0 && leased == 0 {
 			return
-
-		// output channel is ready / queue not empty
-		case popReady && len(queue) > 0:
-			next, queue = queue[0], queue[1:]
-			popc = q.popc
 		}
 	}
 }
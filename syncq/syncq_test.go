@@ -12,7 +12,7 @@ import (
 	"time"
 )
 
-func ExampleQueue_PushPop(t *testing.T) {
+func TestPushPop(t *testing.T) {
 	ctx := context.Background()
 	q := New[int]()
 	defer q.WaitEmpty(ctx)
@@ -413,3 +413,115 @@ func (w *consumers) Go(ctx context.Context, q *Queue[int]) {
 }
 
 func (w *consumers) Wait() error { return w.g.Wait() }
+
+func TestPause(t *testing.T) {
+	t.Run("PopBlocksWhilePaused", func(t *testing.T) {
+		ctx := context.Background()
+		q := New[int]()
+		defer q.WaitEmpty(ctx)
+		q.Pause()
+
+		if err := q.Push(ctx, 3); err != nil {
+			t.Errorf("Push() while paused got error: %s", err)
+		}
+		pctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if v, ok := q.Pop(pctx); ok {
+			t.Errorf("Pop() while paused got (%d, %t) wanted (0, false)", v, ok)
+		}
+
+		q.Resume()
+		if v, ok := q.Pop(ctx); v != 3 || !ok {
+			t.Errorf("Pop() after Resume() got (%d, %t) wanted (%d, %t)", v, ok, 3, true)
+		}
+	})
+	t.Run("ClosedWhilePaused", func(t *testing.T) {
+		ctx := context.Background()
+		q := New[int]()
+		q.Pause()
+		q.Close()
+		if v, ok := q.Pop(ctx); ok {
+			t.Errorf("Pop() got (%d, %t) wanted (0, false)", v, ok)
+		}
+		q.Resume() // does not block once the queue is done
+	})
+}
+
+func TestDrain(t *testing.T) {
+	t.Run("Drained", func(t *testing.T) {
+		ctx := context.Background()
+		q := New[int]()
+		q.Push(ctx, 3)
+
+		go q.Pop(ctx)
+
+		remaining, drained := q.Drain(ctx)
+		if !drained || len(remaining) != 0 {
+			t.Errorf("Drain() got (%v, %t) wanted ([], true)", remaining, drained)
+		}
+	})
+	t.Run("Deadline", func(t *testing.T) {
+		ctx := context.Background()
+		q := New[int]()
+		for i := 1; i <= 3; i++ {
+			q.Push(ctx, i)
+		}
+		if v, ok := q.Pop(ctx); v != 1 || !ok {
+			t.Errorf("Pop() got (%d, %t) wanted (%d, %t)", v, ok, 1, true)
+		}
+
+		dctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		remaining, drained := q.Drain(dctx)
+		if drained {
+			t.Errorf("Drain() got drained=true wanted false")
+		}
+		if diff := cmp.Diff([]int{2, 3}, remaining); diff != "" {
+			t.Errorf("Drain() got diff -want/+got: %s", diff)
+		}
+		if n, _ := q.Size(); n != 0 {
+			t.Errorf("Size() got %d wanted 0", n)
+		}
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	q := New[int]()
+	defer q.Shutdown()
+	for i := 1; i <= 3; i++ {
+		q.Push(ctx, i)
+	}
+	if diff := cmp.Diff([]int{1, 2, 3}, q.Snapshot()); diff != "" {
+		t.Errorf("Snapshot() got diff -want/+got: %s", diff)
+	}
+	q.Pop(ctx)
+	if diff := cmp.Diff([]int{2, 3}, q.Snapshot()); diff != "" {
+		t.Errorf("Snapshot() after Pop() got diff -want/+got: %s", diff)
+	}
+}
+
+func TestShutdownRemaining(t *testing.T) {
+	ctx := context.Background()
+	q := New[int]()
+	for i := 1; i <= 3; i++ {
+		q.Push(ctx, i)
+	}
+	q.Pop(ctx)
+
+	remaining := q.ShutdownRemaining()
+	if diff := cmp.Diff([]int{2, 3}, remaining); diff != "" {
+		t.Errorf("ShutdownRemaining() got diff -want/+got: %s", diff)
+	}
+	// The result is a copy, changing it does not change the queue.
+	remaining[0] = 42
+	if diff := cmp.Diff([]int{2, 3}, q.ShutdownRemaining()); diff != "" {
+		t.Errorf("ShutdownRemaining() again got diff -want/+got: %s", diff)
+	}
+	if diff := cmp.Diff([]int{2, 3}, q.Snapshot()); diff != "" {
+		t.Errorf("Snapshot() after shutdown got diff -want/+got: %s", diff)
+	}
+	if _, ok := q.Pop(ctx); ok {
+		t.Errorf("Pop() after ShutdownRemaining() got open=true wanted false")
+	}
+}